@@ -0,0 +1,61 @@
+package bench
+
+import (
+	"testing"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Runs the compression and decompression benchmarks of every corpus as sub-benchmarks
+func Run(b *testing.B, corpora []Corpus) {
+	for _, corpus := range corpora {
+		corpus := corpus
+		b.Run("Compress/"+corpus.Name, func(b *testing.B) { Compress(b, corpus) })
+		b.Run("Decompress/"+corpus.Name, func(b *testing.B) { Decompress(b, corpus) })
+	}
+}
+
+// Benchmarks compressing the corpus as a single block, reporting MB/s and the compression ratio
+func Compress(b *testing.B, corpus Corpus) {
+	var c doboz.Compressor
+	destination := make([]byte, doboz.GetMaxCompressedSize(len(corpus.Data)))
+	compressedSize := 0
+
+	b.SetBytes(int64(len(corpus.Data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var result doboz.Result
+		if result, compressedSize = c.Compress(corpus.Data, destination); result != doboz.RESULT_OK {
+			b.Fatal(result)
+		}
+	}
+
+	b.ReportMetric(float64(len(corpus.Data))/float64(compressedSize), "ratio")
+}
+
+// Benchmarks decompressing the corpus compressed as a single block, reporting MB/s of uncompressed data
+func Decompress(b *testing.B, corpus Corpus) {
+	var c doboz.Compressor
+	var d doboz.Decompressor
+
+	compressed := make([]byte, doboz.GetMaxCompressedSize(len(corpus.Data)))
+	result, compressedSize := c.Compress(corpus.Data, compressed)
+	if result != doboz.RESULT_OK {
+		b.Fatal(result)
+	}
+	compressed = compressed[:compressedSize]
+
+	destination := make([]byte, len(corpus.Data))
+
+	b.SetBytes(int64(len(corpus.Data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if result := d.Decompress(compressed, destination); result != doboz.RESULT_OK {
+			b.Fatal(result)
+		}
+	}
+
+	b.ReportMetric(float64(len(corpus.Data))/float64(compressedSize), "ratio")
+}
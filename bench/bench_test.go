@@ -0,0 +1,7 @@
+package bench
+
+import "testing"
+
+func BenchmarkDoboz(b *testing.B) {
+	Run(b, Corpora())
+}
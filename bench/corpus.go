@@ -0,0 +1,173 @@
+// Package bench provides benchmarks of doboz over standard and synthetic corpora
+// The benchmark functions take a *testing.B, so they can be run from any test file:
+//
+//	func BenchmarkDoboz(b *testing.B) { bench.Run(b, bench.Corpora()) }
+package bench
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Well known corpora which can be passed to Load
+const (
+	SILESIA_URL = "https://sun.aei.polsl.pl/~sdeor/corpus/silesia.zip"
+	ENWIK8_URL  = "https://mattmahoney.net/dc/enwik8.zip"
+)
+
+// Environment variable listing additional corpora (file paths or URLs, separated by commas) for Corpora
+const CORPORA_ENV = "DOBOZ_BENCH_CORPORA"
+
+const SYNTHETIC_SIZE = 4 << 20
+
+type Corpus struct {
+	Name string
+	Data []byte
+}
+
+// Returns the synthetic corpora and the ones listed in the DOBOZ_BENCH_CORPORA environment variable
+// Corpora that cannot be loaded are skipped, so benchmarks still run offline
+func Corpora() []Corpus {
+	corpora := Synthetic(SYNTHETIC_SIZE)
+
+	for _, location := range strings.Split(os.Getenv(CORPORA_ENV), ",") {
+		if location = strings.TrimSpace(location); location == "" {
+			continue
+		}
+
+		loaded, err := Load(location)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bench: skipping %s: %v\n", location, err)
+			continue
+		}
+		corpora = append(corpora, loaded...)
+	}
+
+	return corpora
+}
+
+// Returns deterministic synthetic corpora of the specified size: random, repetitive and text-like data
+func Synthetic(size int) []Corpus {
+	r := rand.New(rand.NewSource(1))
+
+	random := make([]byte, size)
+	r.Read(random)
+
+	repetitive := make([]byte, size)
+	pattern := random[:1000]
+	for i := range repetitive {
+		repetitive[i] = pattern[i%len(pattern)]
+	}
+
+	words := strings.Fields("the of and to in is that for it as was with be by on not he this are or his from at which")
+	var text bytes.Buffer
+	for text.Len() < size {
+		text.WriteString(words[int(r.ExpFloat64()*4)%len(words)])
+		text.WriteByte(' ')
+	}
+
+	return []Corpus{
+		{Name: "random", Data: random},
+		{Name: "repetitive", Data: repetitive},
+		{Name: "text", Data: text.Bytes()[:size]},
+	}
+}
+
+// Loads corpora from a file path or a URL
+// Downloads are cached in the user's cache directory, and every file of a zip archive becomes a separate corpus
+func Load(location string) ([]Corpus, error) {
+	var data []byte
+	var err error
+
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		data, err = download(location)
+	} else {
+		data, err = os.ReadFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(location)
+	if !strings.HasSuffix(name, ".zip") {
+		return []Corpus{{Name: name, Data: data}}, nil
+	}
+
+	return unzip(data)
+}
+
+// Downloads a file, or returns it from the cache if it was downloaded before
+func download(url string) ([]byte, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256([]byte(url))
+	cacheFile := filepath.Join(cacheDir, "doboz-bench", hex.EncodeToString(hash[:8])+"-"+filepath.Base(url))
+
+	if data, err := os.ReadFile(cacheFile); err == nil {
+		return data, nil
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bench: downloading %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failing to cache is not fatal, the next run downloads again
+	if os.MkdirAll(filepath.Dir(cacheFile), 0755) == nil {
+		os.WriteFile(cacheFile, data, 0644)
+	}
+
+	return data, nil
+}
+
+func unzip(data []byte) ([]Corpus, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var corpora []Corpus
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		corpora = append(corpora, Corpus{Name: filepath.Base(file.Name), Data: content})
+	}
+
+	return corpora, nil
+}
@@ -0,0 +1,135 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+import "bytes"
+
+// The number of tokens Bisect reports before and after the diverging token
+const BISECT_CONTEXT_TOKENS = 8
+
+// A literal run or a match of a compressed block
+type Token struct {
+	IsMatch        bool
+	InputPosition  int // position of the literals or the encoded match in the source
+	OutputPosition int // position of the decoded bytes in the output
+	Length         int // number of decoded bytes
+	Offset         int // match offset, 0 for literals
+}
+
+// The earliest point at which a compressed block fails to decode or differs from the expected data
+type Divergence struct {
+	Result         Result  // the decoding error, or RESULT_OK if the block decodes to different data
+	OutputPosition int     // the first output byte that cannot be decoded or differs
+	InputPosition  int     // the position in the source where the token producing that byte starts
+	Tokens         []Token // the tokens around the diverging one
+	TokenIndex     int     // the index of the diverging token in Tokens, or len(Tokens) if it could not be decoded
+}
+
+// Searches for the earliest output byte at which a block fails to decode or differs from the expected data
+// The expected data may be nil, in which case only decoding failures are searched for
+// The search is a binary search over decoded prefixes, so it is fast even for large blocks
+// Returns the divergence and true, or false if the block decodes to the expected data
+func (d *Decompressor) Bisect(source []byte, expected []byte) (Divergence, bool) {
+	// Decode the header
+	decodeHeaderResult, header, headerSize := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK {
+		return Divergence{Result: decodeHeaderResult}, true
+	}
+
+	if uint64(len(source)) < header.CompressedSize {
+		return Divergence{Result: RESULT_ERROR_BUFFER_TOO_SMALL, InputPosition: len(source)}, true
+	}
+
+	uncompressedSize := int(header.UncompressedSize)
+	comparedSize := uncompressedSize
+	if expected != nil {
+		comparedSize = min(comparedSize, len(expected))
+	}
+
+	// Checks whether the first n bytes decode to the expected data
+	decodes := func(n int) (Result, bool) {
+		result, prefix := d.DecompressPrefix(source, n)
+		if result != RESULT_OK {
+			return result, false
+		}
+
+		return RESULT_OK, expected == nil || bytes.Equal(prefix, expected[:n])
+	}
+
+	// Find the shortest prefix that does not decode to the expected data
+	if _, ok := decodes(comparedSize); ok {
+		// Every byte matches, but the block may still fail at its end or differ in length
+		result := d.Decompress(source, make([]byte, uncompressedSize))
+		if result == RESULT_OK && (expected == nil || len(expected) == uncompressedSize) {
+			return Divergence{}, false
+		}
+
+		divergence := d.divergenceAt(source, header, headerSize, comparedSize)
+		divergence.Result = result
+		return divergence, true
+	}
+
+	low, high := 0, comparedSize // decodes(low) succeeds, decodes(high) fails
+	for high-low > 1 {
+		middle := low + (high-low)/2
+		if _, ok := decodes(middle); ok {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+
+	divergence := d.divergenceAt(source, header, headerSize, high-1)
+	divergence.Result, _ = decodes(high)
+	return divergence, true
+}
+
+// Walks the tokens of a block up to the one producing the output byte at the specified position
+// Collects the tokens around it, and stops at the first decoding error
+func (d *Decompressor) divergenceAt(source []byte, header Header, headerSize int, outputPosition int) Divergence {
+	divergence := Divergence{OutputPosition: outputPosition}
+
+	tokens := d.newTokenReader(source, header, headerSize)
+	after := -1 // the number of tokens still collected after the diverging one, -1 before it is found
+
+	for after != 0 {
+		inputPosition := tokens.inputIterator
+
+		result, tok, ok := tokens.next()
+		if result != RESULT_OK || !ok {
+			if after < 0 {
+				divergence.InputPosition = inputPosition
+				divergence.TokenIndex = len(divergence.Tokens)
+			}
+			break
+		}
+
+		divergence.Tokens = append(divergence.Tokens, Token{
+			IsMatch:        tok.isMatch,
+			InputPosition:  tok.inputPosition,
+			OutputPosition: tok.outputPosition,
+			Length:         tok.length,
+			Offset:         tok.offset,
+		})
+
+		if after > 0 {
+			after--
+			continue
+		}
+
+		if tok.outputPosition+tok.length > outputPosition {
+			divergence.InputPosition = tok.inputPosition
+			divergence.TokenIndex = len(divergence.Tokens) - 1
+			after = BISECT_CONTEXT_TOKENS
+			continue
+		}
+
+		// Only keep the tokens preceding the diverging one
+		if len(divergence.Tokens) > BISECT_CONTEXT_TOKENS {
+			divergence.Tokens = append(divergence.Tokens[:0], divergence.Tokens[1:]...)
+		}
+	}
+
+	return divergence
+}
@@ -0,0 +1,49 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+// The data written to the writers which compress everything written to them as a single block on Close
+type blockBuffer struct {
+	data   []byte
+	closed bool
+}
+
+// Adds p to the buffered data, or returns errClosed if the buffer was closed
+func (b *blockBuffer) write(p []byte, errClosed error) (int, error) {
+	if b.closed {
+		return 0, errClosed
+	}
+
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
+
+// Closes the buffer and compresses the buffered data into a new block
+// Returns no block if the buffer was already closed or holds no data
+func (b *blockBuffer) close(c *Compressor) ([]byte, error) {
+	if b.closed {
+		return nil, nil
+	}
+	b.closed = true
+
+	data := b.data
+	b.data = nil
+
+	return compressBuffer(c, data)
+}
+
+// Compresses a whole buffer into a new block
+// Returns no block if the buffer is empty, as doboz does not compress empty data
+func compressBuffer(c *Compressor, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	destination := make([]byte, c.MaxCompressedSize(len(data)))
+	result, compressedSize := c.Compress(data, destination)
+	if result != RESULT_OK {
+		return nil, result
+	}
+
+	return destination[:compressedSize], nil
+}
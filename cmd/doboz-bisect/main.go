@@ -0,0 +1,86 @@
+// Command doboz-bisect finds the earliest point at which a compressed block fails to decode
+//
+// Usage:
+//
+//	doboz-bisect [-expected file] compressed-file
+//
+// If the expected uncompressed data is supplied, the first byte where the decoded data differs from it is searched
+// for as well. The tokens around the divergence are printed, to help tracking down corruption in a storage layer
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+func main() {
+	expectedPath := flag.String("expected", "", "file with the expected uncompressed data")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-expected file] compressed-file\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	source, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var expected []byte
+	if *expectedPath != "" {
+		if expected, err = os.ReadFile(*expectedPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	var d doboz.Decompressor
+	divergence, found := d.Bisect(source, expected)
+
+	if !found {
+		fmt.Println("the block decodes correctly")
+		return
+	}
+
+	if divergence.Result != doboz.RESULT_OK {
+		fmt.Printf("decoding fails: %v\n", divergence.Result)
+	} else {
+		fmt.Println("decoded data differs from the expected data")
+	}
+	fmt.Printf("output position: %d\n", divergence.OutputPosition)
+	fmt.Printf("input position:  %d\n", divergence.InputPosition)
+
+	fmt.Println()
+
+	for i, token := range divergence.Tokens {
+		marker := "  "
+		if i == divergence.TokenIndex {
+			marker = "> "
+		}
+
+		fmt.Println(marker + formatToken(token))
+	}
+
+	if divergence.TokenIndex == len(divergence.Tokens) && divergence.Result != doboz.RESULT_OK {
+		fmt.Printf("> input %d: %v\n", divergence.InputPosition, divergence.Result)
+	}
+}
+
+func formatToken(token doboz.Token) string {
+	if token.IsMatch {
+		return fmt.Sprintf("input %d: match of %d bytes at offset %d, output %d-%d",
+			token.InputPosition, token.Length, token.Offset, token.OutputPosition, token.OutputPosition+token.Length)
+	}
+
+	return fmt.Sprintf("input %d: literal run of %d bytes, output %d-%d",
+		token.InputPosition, token.Length, token.OutputPosition, token.OutputPosition+token.Length)
+}
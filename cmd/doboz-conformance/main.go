@@ -0,0 +1,57 @@
+// Command doboz-conformance checks an external encoder and decoder against a directory of test vectors
+//
+// Usage:
+//
+//	doboz-conformance [-encoder command] [-decoder command] [-identical] vector-directory
+//
+// The commands are split on whitespace and must follow the conventions of the dobozconformance package.
+// The vector directory is typically written by doboz-vectors. Exits with status 1 if anything does not conform
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/razzie/go-doboz/dobozconformance"
+)
+
+func main() {
+	encoder := flag.String("encoder", "", "encoder command, reading uncompressed data from stdin and writing a block to stdout")
+	decoder := flag.String("decoder", "", "decoder command, reading a block from stdin and writing uncompressed data to stdout")
+	identical := flag.Bool("identical", false, "require the encoder output to be identical to the vectors")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-encoder command] [-decoder command] [-identical] vector-directory\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || (*encoder == "" && *decoder == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	kit := dobozconformance.Kit{
+		Encoder:   strings.Fields(*encoder),
+		Decoder:   strings.Fields(*decoder),
+		Identical: *identical,
+	}
+
+	mismatches, err := kit.Run(flag.Arg(0))
+	for _, mismatch := range mismatches {
+		fmt.Println(mismatch)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if len(mismatches) > 0 {
+		fmt.Printf("%d mismatches\n", len(mismatches))
+		os.Exit(1)
+	}
+
+	fmt.Println("conforms")
+}
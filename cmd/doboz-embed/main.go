@@ -0,0 +1,191 @@
+// Command doboz-embed compresses files at build time into Go source, with accessors which decompress them lazily
+//
+// Usage:
+//
+//	doboz-embed [-o file] [-pkg name] [-func name] [-trim prefix] paths...
+//
+// Every path is a file or a directory, whose files are embedded recursively. The files are named by their slash
+// separated paths, without the prefix given by -trim. It is meant to be run by go:generate:
+//
+//	//go:generate go run github.com/razzie/go-doboz/cmd/doboz-embed -pkg assets -trim static/ static
+//
+// The generated file defines the accessor, by default Asset(name string) ([]byte, bool), which decompresses a file
+// on its first use and returns the cached content afterwards, and AssetNames() []string listing the embedded files
+//
+// The generated file only depends on the names and contents of the files, not on the order of the paths, the file
+// times or the platform, so the builds are reproducible. Files reached through several paths are embedded once, but
+// different files which end up with the same name are an error. An output file which is already up to date is not
+// written again, so its modification time is kept for build caches
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+type embeddedFile struct {
+	name       string
+	size       int
+	compressed []byte
+}
+
+func main() {
+	output := flag.String("o", "assets.go", "output file")
+	pkg := flag.String("pkg", "main", "package of the generated file")
+	function := flag.String("func", "Asset", "name of the accessor function")
+	trim := flag.String("trim", "", "prefix to remove from the file names")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("doboz-embed: no input paths")
+	}
+
+	if !token.IsIdentifier(*pkg) {
+		log.Fatalf("doboz-embed: invalid package name %q", *pkg)
+	}
+
+	if !token.IsIdentifier(*function) {
+		log.Fatalf("doboz-embed: invalid accessor name %q", *function)
+	}
+
+	var files []embeddedFile
+	var c doboz.Compressor
+
+	paths := make(map[string]string) // the path of the file embedded under each name
+
+	for _, root := range flag.Args() {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+
+			path = filepath.Clean(path)
+			name := strings.TrimPrefix(filepath.ToSlash(path), *trim)
+
+			if other, ok := paths[name]; ok {
+				if other == path {
+					return nil
+				}
+				return fmt.Errorf("%s and %s are both embedded as %s", other, path, name)
+			}
+			paths[name] = path
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			file := embeddedFile{
+				name: name,
+				size: len(data),
+			}
+
+			// Empty files are embedded without a block, as doboz does not compress empty data
+			if len(data) > 0 {
+				compressed := make([]byte, doboz.GetMaxCompressedSize(len(data)))
+				result, compressedSize := c.Compress(data, compressed)
+				if result != doboz.RESULT_OK {
+					return fmt.Errorf("%s: %w", path, result)
+				}
+				file.compressed = compressed[:compressedSize]
+			}
+
+			files = append(files, file)
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+
+	source, err := format.Source(generate(*pkg, *function, files))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if previous, err := os.ReadFile(*output); err != nil || !bytes.Equal(previous, source) {
+		if err := os.WriteFile(*output, source, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	uncompressedSize, compressedSize := 0, 0
+	for _, file := range files {
+		uncompressedSize += file.size
+		compressedSize += len(file.compressed)
+	}
+
+	fmt.Printf("embedded %d files, %d bytes compressed to %d, in %s\n", len(files), uncompressedSize, compressedSize, *output)
+}
+
+// Returns the unformatted source of the generated file
+func generate(pkg string, function string, files []embeddedFile) []byte {
+	// The helpers are named after the accessor, so several generated files can share a package, and start with doboz, so
+	// they never collide with the accessor or the function listing the names
+	prefix := "doboz" + function
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// Code generated by doboz-embed; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	fmt.Fprintf(&b, "import (\n\"sync\"\n\ndoboz \"github.com/razzie/go-doboz\"\n)\n\n")
+
+	fmt.Fprintf(&b, "type %sFile struct {\nonce sync.Once\nsize int\ncompressed string\ndata []byte\n}\n\n", prefix)
+
+	fmt.Fprintf(&b, "var %sFiles = map[string]*%sFile{\n", prefix, prefix)
+	for _, file := range files {
+		fmt.Fprintf(&b, "%s: {size: %d, compressed: %s},\n", strconv.Quote(file.name), file.size, strconv.Quote(string(file.compressed)))
+	}
+	fmt.Fprintf(&b, "}\n\n")
+
+	fmt.Fprintf(&b, "var %sNames = []string{\n", prefix)
+	for _, file := range files {
+		fmt.Fprintf(&b, "%s,\n", strconv.Quote(file.name))
+	}
+	fmt.Fprintf(&b, "}\n\n")
+
+	fmt.Fprintf(&b, `// Returns the content of an embedded file, which is decompressed on the first call and cached
+// The returned slice is shared by every caller, so it must not be modified
+func %[1]s(name string) ([]byte, bool) {
+	file, ok := %[2]sFiles[name]
+	if !ok {
+		return nil, false
+	}
+
+	file.once.Do(func() {
+		file.data = make([]byte, file.size)
+		if file.size == 0 {
+			return
+		}
+
+		var d doboz.Decompressor
+		if result := d.Decompress([]byte(file.compressed), file.data); result != doboz.RESULT_OK {
+			panic(result)
+		}
+	})
+
+	return file.data, true
+}
+
+// Returns the names of the embedded files in sorted order
+func %[1]sNames() []string {
+	return append([]string(nil), %[2]sNames...)
+}
+`, function, prefix)
+
+	return b.Bytes()
+}
@@ -0,0 +1,72 @@
+// Command doboz-serve runs the dobozhttp handlers as a service, such as a sidecar container
+//
+// Usage:
+//
+//	doboz-serve [-listen address] [-max-body-size bytes] [-max-decoded-size bytes] [-checksum]
+//	            [-read-header-timeout duration] [-read-timeout duration] [-idle-timeout duration]
+//
+// It serves POST /compress and POST /decompress, and GET /healthz, which answers 200 while the service is running
+// The timeouts bound how long slow clients can hold a connection, 0 disables them
+// The service shuts down gracefully on SIGINT and SIGTERM, finishing the requests in progress
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/razzie/go-doboz/dobozhttp"
+)
+
+func main() {
+	listen := flag.String("listen", ":8080", "address to listen on")
+	maxBodySize := flag.Int64("max-body-size", dobozhttp.DEFAULT_MAX_BODY_SIZE, "limit of the request body size")
+	maxDecodedSize := flag.Uint64("max-decoded-size", 0, "limit of the decompressed size, 0 means the limit of the body size")
+	checksum := flag.Bool("checksum", false, "send the CRC-32C checksum of the responses")
+	readHeaderTimeout := flag.Duration("read-header-timeout", 10*time.Second, "limit of the time to read the request headers")
+	readTimeout := flag.Duration("read-timeout", time.Minute, "limit of the time to read the whole request, including the body")
+	idleTimeout := flag.Duration("idle-timeout", 2*time.Minute, "limit of the time to wait for the next request on a kept-alive connection")
+	flag.Parse()
+
+	mux := dobozhttp.NewServeMux(
+		&dobozhttp.CompressHandler{MaxBodySize: *maxBodySize, Checksum: *checksum},
+		&dobozhttp.DecompressHandler{MaxBodySize: *maxBodySize, MaxDecodedSize: *maxDecodedSize, Checksum: *checksum},
+	)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok\n")
+	})
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           mux,
+		ReadHeaderTimeout: *readHeaderTimeout,
+		ReadTimeout:       *readTimeout,
+		IdleTimeout:       *idleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// ListenAndServe returns as soon as the shutdown starts, so wait for the requests in progress separately
+	shutdown := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+		close(shutdown)
+	}()
+
+	log.Printf("doboz-serve: listening on %s", *listen)
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+
+	<-shutdown
+}
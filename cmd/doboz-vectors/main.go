@@ -0,0 +1,118 @@
+// Command doboz-vectors writes a set of test vectors for other implementations of the doboz format
+//
+// Usage:
+//
+//	doboz-vectors [-o directory]
+//
+// The vectors are the embedded dobozvectors set extended with every size from 1 to 4 times the tail length
+// and the sizes around each header width boundary. Every vector is written as <name>.raw and <name>.doboz,
+// the layout CompareReference reads, and a MANIFEST file lists one vector per line:
+//
+//	<name> <uncompressed size> <compressed size> <covered cases...>
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	doboz "github.com/razzie/go-doboz"
+	"github.com/razzie/go-doboz/dobozvectors"
+)
+
+func main() {
+	dir := flag.String("o", "vectors", "output directory")
+	flag.Parse()
+
+	vectors := append(dobozvectors.Generate(), sizeVectors()...)
+
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	manifestFile, err := os.Create(filepath.Join(*dir, "MANIFEST"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	manifest := bufio.NewWriter(manifestFile)
+
+	for _, vector := range vectors {
+		cases, err := dobozvectors.Cases(vector)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(*dir, vector.Name+".raw"), vector.Uncompressed, 0644); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(*dir, vector.Name+".doboz"), vector.Compressed, 0644); err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Fprintf(manifest, "%s %d %d %s\n", vector.Name, len(vector.Uncompressed), len(vector.Compressed), strings.Join(cases, " "))
+	}
+
+	if err := manifest.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if err := manifestFile.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("wrote %d vectors to %s\n", len(vectors), *dir)
+}
+
+// Returns vectors of every size near the empty block and around the header width boundaries
+// Each size comes as a repetitive block, which compresses, and a random block, which is stored
+func sizeVectors() []dobozvectors.Vector {
+	r := rand.New(rand.NewSource(1))
+
+	var sizes []int
+	for size := 1; size <= 4*doboz.TAIL_LENGTH; size++ {
+		sizes = append(sizes, size)
+	}
+
+	// The header width follows the maximum compressed size, so find the sizes where it changes
+	headerSize := doboz.GetMaxCompressedSize(0)
+	for _, limit := range []int{255, 65535} {
+		boundary := limit - headerSize
+		sizes = append(sizes, boundary, boundary+1)
+	}
+
+	var c doboz.Compressor
+	var vectors []dobozvectors.Vector
+
+	for _, size := range sizes {
+		repetitive := make([]byte, size)
+		for i := range repetitive {
+			repetitive[i] = "doboz"[i%5]
+		}
+
+		random := make([]byte, size)
+		r.Read(random)
+
+		for _, in := range []struct {
+			kind string
+			data []byte
+		}{{"repetitive", repetitive}, {"random", random}} {
+			compressed := make([]byte, doboz.GetMaxCompressedSize(size))
+			result, compressedSize := c.Compress(in.data, compressed)
+			if result != doboz.RESULT_OK {
+				log.Fatalf("compressing %d bytes: %v", size, result)
+			}
+
+			vectors = append(vectors, dobozvectors.Vector{
+				Name:         fmt.Sprintf("size-%d-%s", size, in.kind),
+				Uncompressed: in.data,
+				Compressed:   compressed[:compressedSize],
+			})
+		}
+	}
+
+	return vectors
+}
@@ -0,0 +1,44 @@
+package doboz
+
+// The name of the doboz codec, as reported by the Codec returned by NewCodec
+const CODEC_NAME = "doboz"
+
+// A block compression codec, the common shape of the compression plug-ins of storage engines and RPC frameworks
+// NewCodec returns the doboz implementation, and CodecFuncs adapts other codecs to the interface
+type Codec interface {
+	// Returns the name identifying the codec
+	Name() string
+
+	// Returns the maximum encoded size of a source of the specified size, or a negative number if it is too large
+	MaxEncodedLen(srcLen int) int
+
+	// Encodes the source and returns the encoded data, which is written to dst if it is large enough
+	Encode(dst, src []byte) ([]byte, error)
+
+	// Decodes the source and returns the decoded data, which is written to dst if it is large enough
+	Decode(dst, src []byte) ([]byte, error)
+}
+
+// Adapts functions to the Codec interface, such as the Encode and Decode functions of other compression packages
+type CodecFuncs struct {
+	NameString        string
+	MaxEncodedLenFunc func(srcLen int) int
+	EncodeFunc        func(dst, src []byte) ([]byte, error)
+	DecodeFunc        func(dst, src []byte) ([]byte, error)
+}
+
+func (f CodecFuncs) Name() string {
+	return f.NameString
+}
+
+func (f CodecFuncs) MaxEncodedLen(srcLen int) int {
+	return f.MaxEncodedLenFunc(srcLen)
+}
+
+func (f CodecFuncs) Encode(dst, src []byte) ([]byte, error) {
+	return f.EncodeFunc(dst, src)
+}
+
+func (f CodecFuncs) Decode(dst, src []byte) ([]byte, error) {
+	return f.DecodeFunc(dst, src)
+}
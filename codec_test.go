@@ -0,0 +1,47 @@
+//go:build !dobozdecodeonly && !dobozencodeonly
+
+package doboz_test
+
+import (
+	"bytes"
+	"testing"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+func TestCodecMaxEncodedLen(t *testing.T) {
+	codecs := map[string]doboz.Codec{
+		"default":     doboz.NewCodec(0),
+		"wide header": doboz.NewCodec(0, doboz.WithWideHeader()),
+	}
+
+	inputs := [][]byte{[]byte("a"), bytes.Repeat([]byte("codec "), 1000), make([]byte, 100000)}
+
+	for name, codec := range codecs {
+		for _, input := range inputs {
+			maxEncodedLen := codec.MaxEncodedLen(len(input))
+
+			// A destination of exactly MaxEncodedLen bytes must be used as it is
+			dst := make([]byte, maxEncodedLen)
+			encoded, err := codec.Encode(dst, input)
+			if err != nil {
+				t.Errorf("%s, %d bytes: Encode: %v", name, len(input), err)
+				continue
+			}
+
+			if len(encoded) > maxEncodedLen || &encoded[0] != &dst[0] {
+				t.Errorf("%s, %d bytes: encoded %d bytes outside the destination of %d bytes", name, len(input),
+					len(encoded), maxEncodedLen)
+			}
+
+			decoded, err := codec.Decode(nil, encoded)
+			if err != nil || !bytes.Equal(decoded, input) {
+				t.Errorf("%s, %d bytes: Decode: %v", name, len(input), err)
+			}
+		}
+
+		if got := codec.MaxEncodedLen(-1); got >= 0 {
+			t.Errorf("%s: MaxEncodedLen(-1) = %d", name, got)
+		}
+	}
+}
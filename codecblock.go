@@ -0,0 +1,97 @@
+//go:build !dobozdecodeonly && !dobozencodeonly
+
+package doboz
+
+import "sync"
+
+type blockCodec struct {
+	compressors    sync.Pool
+	maxDecodedSize uint64
+}
+
+// Returns a Codec encoding every source as a single doboz block, which is safe for concurrent use
+// The sources are compressed by pooled Compressors with the specified options, and decoding rejects blocks larger
+// than maxDecodedSize bytes, 0 means no limit
+// Empty sources are encoded as empty data, as doboz does not compress empty blocks
+func NewCodec(maxDecodedSize uint64, options ...CompressorOption) Codec {
+	codec := &blockCodec{}
+	codec.compressors.New = func() interface{} {
+		return NewCompressor(options...)
+	}
+	codec.maxDecodedSize = maxDecodedSize
+
+	return codec
+}
+
+func (b *blockCodec) Name() string {
+	return CODEC_NAME
+}
+
+// The maximum size depends on the options of the Compressors, such as WithWideHeader
+func (b *blockCodec) MaxEncodedLen(srcLen int) int {
+	c := b.compressors.Get().(*Compressor)
+	defer b.compressors.Put(c)
+
+	return maxEncodedLen(c, srcLen)
+}
+
+// Returns the maximum compressed size of a source of the specified size with the compressor, or -1 if it is too large
+func maxEncodedLen(c *Compressor, srcLen int) int {
+	// MaxCompressedSize is never 0 for valid sizes, as it includes the header
+	maxCompressedSize := c.MaxCompressedSize(srcLen)
+	if maxCompressedSize == 0 {
+		return -1
+	}
+
+	return maxCompressedSize
+}
+
+func (b *blockCodec) Encode(dst, src []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return dst[:0], nil
+	}
+
+	c := b.compressors.Get().(*Compressor)
+	defer b.compressors.Put(c)
+
+	maxCompressedSize := maxEncodedLen(c, len(src))
+	if maxCompressedSize < 0 {
+		return nil, RESULT_ERROR_SIZE_LIMIT_EXCEEDED
+	}
+
+	if cap(dst) < maxCompressedSize {
+		dst = make([]byte, maxCompressedSize)
+	}
+
+	result, compressedSize := c.Compress(src, dst[:maxCompressedSize])
+	if result != RESULT_OK {
+		return nil, result
+	}
+
+	return dst[:compressedSize], nil
+}
+
+func (b *blockCodec) Decode(dst, src []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return dst[:0], nil
+	}
+
+	// Decompressors are cheap to create, unlike Compressors
+	d := Decompressor{maxDecodedSize: b.maxDecodedSize}
+
+	result, info := d.GetCompressionInfo(src)
+	if result != RESULT_OK {
+		return nil, result
+	}
+
+	if uint64(cap(dst)) < info.UncompressedSize {
+		dst = make([]byte, info.UncompressedSize)
+	}
+	dst = dst[:info.UncompressedSize]
+
+	if result := d.Decompress(src, dst); result != RESULT_OK {
+		return nil, result
+	}
+
+	return dst, nil
+}
@@ -1,6 +1,9 @@
 package doboz
 
-import "encoding/binary"
+import (
+	"io"
+	"math"
+)
 
 type Result int
 
@@ -9,8 +12,43 @@ const (
 	RESULT_ERROR_BUFFER_TOO_SMALL
 	RESULT_ERROR_CORRUPTED_DATA
 	RESULT_ERROR_UNSUPPORTED_VERSION
+	RESULT_ERROR_SIZE_LIMIT_EXCEEDED
+	RESULT_ERROR_TRAILING_DATA
+	RESULT_ERROR_OUTPUT_SIZE_MISMATCH
+	RESULT_ERROR_STREAM_SIZE_MISMATCH
+	RESULT_ERROR_TRUNCATED
 )
 
+var resultStrings = [...]string{
+	RESULT_OK:                         "doboz: ok",
+	RESULT_ERROR_BUFFER_TOO_SMALL:     "doboz: buffer too small",
+	RESULT_ERROR_CORRUPTED_DATA:       "doboz: corrupted data",
+	RESULT_ERROR_UNSUPPORTED_VERSION:  "doboz: unsupported version",
+	RESULT_ERROR_SIZE_LIMIT_EXCEEDED:  "doboz: size limit exceeded",
+	RESULT_ERROR_TRAILING_DATA:        "doboz: trailing data after compressed block",
+	RESULT_ERROR_OUTPUT_SIZE_MISMATCH: "doboz: output size does not match header",
+	RESULT_ERROR_STREAM_SIZE_MISMATCH: "doboz: stream size does not match header",
+	RESULT_ERROR_TRUNCATED:            "doboz: truncated data",
+}
+
+// Results other than RESULT_OK can be returned as errors by APIs built on the io interfaces
+func (r Result) Error() string {
+	if r >= 0 && int(r) < len(resultStrings) {
+		return resultStrings[r]
+	}
+	return "doboz: unknown error"
+}
+
+// Converts the result to an error, which is nil for RESULT_OK
+func (r Result) Err() error {
+	if r == RESULT_OK {
+		return nil
+	}
+	return r
+}
+
+// A match token, which copies Length bytes starting Offset bytes before the current output position
+// The copied bytes may overlap the bytes being written, so short offsets repeat the last bytes of the output
 type Match struct {
 	Length int
 	Offset int
@@ -23,6 +61,8 @@ type Header struct {
 	IsStored         bool
 }
 
+//go:generate go run gen_ksy.go -o doboz.ksy
+
 const (
 	VERSION = 0 // encoding format
 
@@ -37,16 +77,92 @@ const (
 	TRAILING_DUMMY_SIZE = WORD_SIZE     // safety trailing bytes which decrease the number of necessary buffer checks
 )
 
+// The sizes of the header with 4-byte and with 8-byte size fields
+const (
+	narrowHeaderSize = 1 + 2*4
+	wideHeaderSize   = 1 + 2*8
+)
+
+// Returns the maximum compressed size of any block of data with the specified size
+// This function should be used to determine the size of the compression destination buffer
+// Returns 0 if the size is negative or the maximum compressed size does not fit in an int, which can be told apart
+// from valid sizes with GetMaxCompressedSizeChecked
+func GetMaxCompressedSize(size int) int {
+	_, maxCompressedSize := GetMaxCompressedSizeChecked(size)
+	return maxCompressedSize
+}
+
+// Returns the maximum compressed size of any block of data with the specified size, like GetMaxCompressedSize
+// Returns RESULT_ERROR_SIZE_LIMIT_EXCEEDED if the size is negative or the maximum compressed size does not fit in an int
+// On success, returns RESULT_OK and the maximum compressed size
+func GetMaxCompressedSizeChecked(size int) (Result, int) {
+	if size < 0 || size > MaxInt-narrowHeaderSize {
+		return RESULT_ERROR_SIZE_LIMIT_EXCEEDED, 0
+	}
+
+	// The header + the original uncompressed data
+	// The size fields of the header are at most 4 bytes wide, unless the sizes do not fit in 32 bits
+	maxCompressedSize := narrowHeaderSize + size
+	if getSizeCodedSize(maxCompressedSize) > 4 {
+		if size > MaxInt-wideHeaderSize {
+			return RESULT_ERROR_SIZE_LIMIT_EXCEEDED, 0
+		}
+
+		maxCompressedSize = wideHeaderSize + size
+	}
+
+	return RESULT_OK, maxCompressedSize
+}
+
+// Returns the size of the header Compress writes for a block of data with the specified size, with the default options
+// The header size only depends on the size of the data, not on how well it compresses
+// Returns 0 if the size is negative or too large, like GetMaxCompressedSize
+func GetHeaderSize(size int) int {
+	result, maxCompressedSize := GetMaxCompressedSizeChecked(size)
+	if result != RESULT_OK {
+		return 0
+	}
+
+	return getHeaderSize(maxCompressedSize)
+}
+
+// Returns the width in bytes of each of the two size fields of a header, given the maximum compressed size of the block
+// The fields are 1, 2, 4 or 8 bytes wide, the smallest width that can hold the maximum compressed size
+func GetSizeCodedSize(maxCompressedSize int) int {
+	return getSizeCodedSize(maxCompressedSize)
+}
+
+func getHeaderSize(maxCompressedSize int) int {
+	return 1 + 2*getSizeCodedSize(maxCompressedSize)
+}
+
+func getSizeCodedSize(size int) int {
+	if size <= 255 {
+		return 1
+	}
+
+	if size <= 65535 {
+		return 2
+	}
+
+	if uint64(size) <= math.MaxUint32 {
+		return 4
+	}
+
+	return 8
+}
+
 // Reads up to 4 bytes and returns them in a word
+// The bytes are always read in little-endian order, regardless of the platform
 // WARNING: May read more bytes than requested!
 func FastRead(source []byte, size int) uint {
 	switch size {
 	case 4:
-		return uint(binary.LittleEndian.Uint32(source))
+		return uint(load32(source))
 	case 3:
-		return uint(binary.LittleEndian.Uint32(source))
+		return uint(load32(source))
 	case 2:
-		return uint(binary.LittleEndian.Uint16(source))
+		return uint(load16(source))
 	case 1:
 		return uint(source[0])
 	default:
@@ -55,20 +171,43 @@ func FastRead(source []byte, size int) uint {
 }
 
 // Writes up to 4 bytes specified in a word
+// The bytes are always written in little-endian order, regardless of the platform
 // WARNING: May write more bytes than requested!
 func FastWrite(destination []byte, word uint, size int) {
 	switch size {
 	case 4:
-		binary.LittleEndian.PutUint32(destination, uint32(word))
+		store32(destination, uint32(word))
 	case 3:
-		binary.LittleEndian.PutUint32(destination, uint32(word))
+		store32(destination, uint32(word))
 	case 2:
-		binary.LittleEndian.PutUint16(destination, uint16(word))
+		store16(destination, uint16(word))
 	case 1:
 		destination[0] = byte(word)
 	}
 }
 
+// The names of the implementations returned by Implementation
+const (
+	IMPLEMENTATION_GENERIC = "generic" // portable Go code only, in builds with the noasm build tag
+	IMPLEMENTATION_UNSAFE  = "unsafe"  // string conversions sharing memory through unsafe
+)
+
+// Returns the name of the implementation the package was built with, for diagnostics and benchmark reports
+// It is selected by the noasm build tag and never changes at runtime, so benchmarks pin a variant by building with or
+// without the tag
+func Implementation() string {
+	if pureGo {
+		return IMPLEMENTATION_GENERIC
+	}
+	return IMPLEMENTATION_UNSAFE
+}
+
+// Reports whether the portable implementation without unsafe conversions is active
+// It is in builds with the noasm build tag
+func PureGo() bool {
+	return pureGo
+}
+
 const (
 	MaxUint = ^uint(0)
 	MinUint = 0
@@ -101,3 +240,13 @@ func Hash(data []byte, pos int) uint {
 
 	return result
 }
+
+// Writes p to w, turning a short write without an error into io.ErrShortWrite
+func writeAll(w io.Writer, p []byte) error {
+	n, err := w.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+
+	return err
+}
@@ -0,0 +1,78 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// Compresses data through a cache keyed by the SHA-256 hash of the data, for services sending the same data repeatedly
+// The compressed blocks are cached up to a byte budget, the least recently used ones are evicted first, and blocks
+// larger than the budget are never cached
+// A CompressionCache is safe for concurrent use
+type CompressionCache struct {
+	compressors sync.Pool
+
+	mu    sync.Mutex // guards the cache
+	cache *lruCache  // keyed by the hash of the data
+}
+
+// Creates a CompressionCache holding at most budget bytes of compressed data, compressing with the specified options
+func NewCompressionCache(budget int, options ...CompressorOption) *CompressionCache {
+	cache := &CompressionCache{cache: newLRUCache(budget)}
+	cache.compressors.New = func() interface{} {
+		return NewCompressor(options...)
+	}
+
+	return cache
+}
+
+// Returns the compressed form of the source, from the cache if the same data was compressed before
+// The returned slice is shared with the cache and other callers, so it must not be modified
+// On success, returns RESULT_OK and the compressed data, the same results apply as for Compress otherwise
+func (cc *CompressionCache) Compress(source []byte) (Result, []byte) {
+	hash := sha256.Sum256(source)
+	key := string(hash[:])
+
+	cc.mu.Lock()
+	if compressed, ok := cc.cache.get(key); ok {
+		cc.mu.Unlock()
+		return RESULT_OK, compressed
+	}
+	cc.mu.Unlock()
+
+	c := cc.compressors.Get().(*Compressor)
+	destination := make([]byte, c.MaxCompressedSize(len(source)))
+	result, compressedSize := c.Compress(source, destination)
+	cc.compressors.Put(c)
+
+	if result != RESULT_OK {
+		return result, nil
+	}
+
+	compressed := destination[:compressedSize:compressedSize]
+
+	// Another caller may have compressed the same data in the meantime, the block cached first is kept
+	cc.mu.Lock()
+	cc.cache.add(key, compressed)
+	cc.mu.Unlock()
+
+	return RESULT_OK, compressed
+}
+
+// Returns the number of compressed bytes held by the cache
+func (cc *CompressionCache) Size() int {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	return cc.cache.size
+}
+
+// Removes every block from the cache
+func (cc *CompressionCache) Purge() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.cache.purge()
+}
@@ -0,0 +1,44 @@
+//go:build !dobozdecodeonly && !tinygo
+
+package doboz
+
+import (
+	"context"
+	"log/slog"
+	"runtime/pprof"
+	"time"
+)
+
+// Compresses with logging and profiler labels, see WithCompressLogger and WithCompressProfileLabels
+func (c *Compressor) compressInstrumented(source []byte, destination []byte) (result Result, compressedSize int) {
+	start := time.Now()
+
+	if c.profile {
+		labels := profileLabels("compress", len(source), c.profileLabels)
+		pprof.Do(context.Background(), labels, func(context.Context) {
+			result, compressedSize = c.compress(source, destination)
+		})
+	} else {
+		result, compressedSize = c.compress(source, destination)
+	}
+
+	if c.logger != nil {
+		c.logCompress(len(source), compressedSize, result, time.Since(start))
+	}
+
+	return result, compressedSize
+}
+
+func (c *Compressor) logCompress(uncompressedSize int, compressedSize int, result Result, duration time.Duration) {
+	if result != RESULT_OK {
+		c.logger.LogAttrs(context.Background(), slog.LevelDebug, "doboz: compression failed",
+			slog.Int("uncompressedSize", uncompressedSize),
+			slog.String("error", result.Error()))
+		return
+	}
+
+	c.logger.LogAttrs(context.Background(), slog.LevelDebug, "doboz: compressed block",
+		slog.Int("uncompressedSize", uncompressedSize),
+		slog.Int("compressedSize", compressedSize),
+		slog.Duration("duration", duration))
+}
@@ -0,0 +1,55 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+import "bytes"
+
+// A destination that can reserve room for the data written to it, such as a bytes.Buffer
+// Sinks which also have an AvailableBuffer method, like bytes.Buffer, are compressed into directly
+type Sink interface {
+	Grow(n int)
+	Write(p []byte) (int, error)
+}
+
+// Compresses a block of data and appends it to a bytes.Buffer
+// The buffer is grown once to fit the maximum compressed size, and the block is compressed directly into it
+// On success, returns RESULT_OK and outputs the compressed size
+func (c *Compressor) CompressToBuffer(source []byte, buffer *bytes.Buffer) (Result, int) {
+	n, err := c.CompressInto(source, buffer)
+	if err != nil {
+		return err.(Result), 0
+	}
+
+	return RESULT_OK, n
+}
+
+// Compresses a block of data and writes it to a sink
+// The sink is grown once to fit the maximum compressed size
+// If the sink has an AvailableBuffer method, the block is compressed directly into the returned buffer, which is then
+// written to the sink without a copy, otherwise it is compressed into a temporary buffer first
+// Returns the compressed size, and nil on success, the Result if compression fails, or the error of the sink
+func (c *Compressor) CompressInto(source []byte, sink Sink) (int, error) {
+	maxCompressedSize := c.MaxCompressedSize(len(source))
+	sink.Grow(maxCompressedSize)
+
+	var destination []byte
+	if available, ok := sink.(interface{ AvailableBuffer() []byte }); ok {
+		destination = available.AvailableBuffer()
+	}
+
+	if cap(destination) < maxCompressedSize {
+		destination = make([]byte, maxCompressedSize)
+	}
+	destination = destination[:maxCompressedSize]
+
+	result, compressedSize := c.Compress(source, destination)
+	if result != RESULT_OK {
+		return 0, result
+	}
+
+	if err := writeAll(sink, destination[:compressedSize]); err != nil {
+		return 0, err
+	}
+
+	return compressedSize, nil
+}
@@ -0,0 +1,9 @@
+//go:build !dobozdecodeonly && tinygo
+
+package doboz
+
+// TinyGo builds leave out the logging and profiling machinery,
+// so WithCompressLogger and WithCompressProfileLabels have no effect
+func (c *Compressor) compressInstrumented(source []byte, destination []byte) (Result, int) {
+	return c.compress(source, destination)
+}
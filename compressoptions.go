@@ -0,0 +1,95 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+import "log/slog"
+
+// Configures a Compressor created with NewCompressor
+type CompressorOption func(*Compressor)
+
+// Creates a new Compressor with the specified options applied
+// A zero value Compressor is also ready to use
+func NewCompressor(options ...CompressorOption) *Compressor {
+	c := new(Compressor)
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Fills the supplied statistics during every successful Compress call
+func WithCompressStats(stats *CompressStats) CompressorOption {
+	return func(c *Compressor) {
+		c.stats = stats
+	}
+}
+
+// Collects histograms of the match lengths, match offsets, match coded sizes and literal runs into CompressStats.Histograms
+// Has no effect without WithCompressStats
+func WithCompressHistograms() CompressorOption {
+	return func(c *Compressor) {
+		c.histograms = true
+	}
+}
+
+// Reports every literal, match, control word and block encoded by Compress to the tracer
+func WithCompressTracer(tracer Tracer) CompressorOption {
+	return func(c *Compressor) {
+		c.tracer = tracer
+	}
+}
+
+// Logs compressed blocks, fallbacks to storing and their timing to the logger at Debug level
+// Has no effect under TinyGo
+func WithCompressLogger(logger *slog.Logger) CompressorOption {
+	return func(c *Compressor) {
+		c.logger = logger
+	}
+}
+
+// Runs Compress with runtime/pprof labels for the operation and the input size bucket, so CPU profiles attribute the time to doboz
+// The optional labels are additional key-value pairs, such as the name of the call site, and their count must be even
+// Has no effect under TinyGo
+func WithCompressProfileLabels(labels ...string) CompressorOption {
+	return func(c *Compressor) {
+		c.profile = true
+		c.profileLabels = labels
+	}
+}
+
+// Checks the byte distribution of the data before compressing it, and stores data that looks already compressed
+// or encrypted without running the match finder
+// This makes incompressible data much cheaper to process, but data which only compresses through long matches
+// over otherwise random bytes is stored as well
+func WithEntropyCheck() CompressorOption {
+	return func(c *Compressor) {
+		c.entropyCheck = true
+	}
+}
+
+// Always encodes the sizes in the header with 8 bytes, regardless of the size of the block
+// The header is then always 17 bytes long, with the uncompressed size at offset 1 and the compressed size at offset 9,
+// both in little-endian order, so they can be patched in place once they are known
+// The destination buffer must be at least MaxCompressedSize bytes, which is 8 bytes more than GetMaxCompressedSize
+func WithWideHeader() CompressorOption {
+	return func(c *Compressor) {
+		c.wideHeader = true
+	}
+}
+
+// Clears the internal state of the compressor after every Compress, CompressedSize, CompressibilityReport and step
+// compression, so no trace of sensitive data lingers in it
+// The match finder only stores positions, but they reveal which parts of the data repeat
+func WithCompressWipe() CompressorOption {
+	return func(c *Compressor) {
+		c.wipe = true
+	}
+}
+
+// Injects the supplied faults into every Compress call, see Faults
+// Meant for tests only
+func WithCompressFaults(faults *Faults) CompressorOption {
+	return func(c *Compressor) {
+		c.faults = faults
+	}
+}
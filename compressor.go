@@ -1,115 +1,259 @@
+//go:build !dobozdecodeonly
+
 package doboz
 
 import (
 	"encoding/binary"
+	"log/slog"
 )
 
 type Compressor struct {
 	dict Dictionary
-}
 
-// Returns the maximum compressed size of any block of data with the specified size
-// This function should be used to determine the size of the compression destination buffer
-func GetMaxCompressedSize(size int) int {
-	// The header + the original uncompressed data
-	return getHeaderSize(MaxInt) + size
+	stats      *CompressStats
+	histograms bool
+	tracer     Tracer
+	logger     *slog.Logger
+
+	entropyCheck bool
+	wideHeader   bool
+	wipe         bool
+	faults       *Faults
+
+	profile       bool
+	profileLabels []string
 }
 
-func getHeaderSize(maxCompressedSize int) int {
-	return 1 + 2*getSizeCodedSize(maxCompressedSize)
+// Compresses a block of data
+// The source and destination buffers must not overlap and their size must be greater than 0
+// A destination of GetMaxCompressedSize bytes always suffices, a smaller one works as long as the compressed data fits,
+// otherwise RESULT_ERROR_BUFFER_TOO_SMALL is returned
+// The encoder needs a few bytes of room beyond the data it writes, so it may give up on a destination which is at most
+// 12 bytes larger than the compressed size
+// This operation is memory safe
+// Blocks too large for the platform, which is only possible on 32-bit platforms, fail with RESULT_ERROR_SIZE_LIMIT_EXCEEDED
+// On success, returns RESULT_OK and outputs the compressed size
+func (c *Compressor) Compress(source []byte, destination []byte) (Result, int) {
+	if c.logger == nil && !c.profile {
+		return c.compress(source, destination)
+	}
+
+	return c.compressInstrumented(source, destination)
 }
 
-func getSizeCodedSize(size int) int {
-	if size <= 255 {
-		return 1
+func (c *Compressor) compress(source []byte, destination []byte) (Result, int) {
+	if c.faults != nil {
+		source, destination = c.faults.apply(source, destination)
 	}
 
-	if size <= 65535 {
-		return 2
+	if len(source) == 0 {
+		return RESULT_ERROR_BUFFER_TOO_SMALL, 0
 	}
 
-	/*if (size <= MaxUint) {
-	    return 4
+	if exceedsMaxSourceSize(len(source)) {
+		return RESULT_ERROR_SIZE_LIMIT_EXCEEDED, 0
 	}
 
-	return 8*/
+	if destination == nil {
+		return RESULT_ERROR_BUFFER_TOO_SMALL, 0
+	}
 
-	return 4
+	return c.encode(source, destination)
 }
 
-// Compresses a block of data
-// The source and destination buffers must not overlap and their size must be greater than 0
-// This operation is memory safe
-// On success, returns RESULT_OK and outputs the compressed size
-func (c *Compressor) Compress(source []byte, destination []byte) (Result, int) {
+// Computes the exact compressed size of a block of data without writing any output
+// It runs the same encoder as Compress, so the result always equals the size Compress would output
+// On success, returns RESULT_OK and the compressed size
+func (c *Compressor) CompressedSize(source []byte) (Result, int) {
 	if len(source) == 0 {
 		return RESULT_ERROR_BUFFER_TOO_SMALL, 0
 	}
 
-	maxCompressedSize := GetMaxCompressedSize(len(source))
-	if len(destination) < maxCompressedSize {
-		return RESULT_ERROR_BUFFER_TOO_SMALL, 0
+	if exceedsMaxSourceSize(len(source)) {
+		return RESULT_ERROR_SIZE_LIMIT_EXCEEDED, 0
 	}
 
-	inputBuffer := source
-	outputBuffer := destination
+	return c.encode(source, nil)
+}
+
+// Returns the maximum compressed size of any block of data with the specified size, taking the options of the
+// compressor into account
+// This equals GetMaxCompressedSize, unless the compressor was created with WithWideHeader
+// Returns 0 if the size is negative or the maximum compressed size does not fit in an int
+func (c *Compressor) MaxCompressedSize(size int) int {
+	if c.wideHeader {
+		if size < 0 || size > MaxInt-wideHeaderSize {
+			return 0
+		}
+
+		return wideHeaderSize + size
+	}
+
+	return GetMaxCompressedSize(size)
+}
+
+// Returns the size of the header of a block with the specified maximum compressed size
+func (c *Compressor) getHeaderSize(maxCompressedSize int) int {
+	return 1 + 2*c.getSizeCodedSize(maxCompressedSize)
+}
+
+// Returns the size of the size fields in the header of a block with the specified maximum compressed size
+func (c *Compressor) getSizeCodedSize(maxCompressedSize int) int {
+	if c.wideHeader {
+		return 8
+	}
+
+	return getSizeCodedSize(maxCompressedSize)
+}
+
+// Checks whether a block of data is too large for its maximum compressed size to fit in an int
+// This can only happen on 32-bit platforms, where int is limited to 2 GB
+func exceedsMaxSourceSize(size int) bool {
+	return size > MaxInt-wideHeaderSize
+}
+
+// Clears the internal state of the compressor, which still refers to the last compressed data
+// Compressors created with WithCompressWipe do this after every operation
+func (c *Compressor) Wipe() {
+	c.dict.Wipe()
+}
+
+// Encodes a non-empty block of data
+// If the destination is smaller than MaxCompressedSize bytes and the output does not fit, returns RESULT_ERROR_BUFFER_TOO_SMALL
+// If the destination is nil, nothing is written, only the compressed size is computed
+func (c *Compressor) encode(source []byte, destination []byte) (Result, int) {
+	if c.wipe {
+		defer c.Wipe()
+	}
+
+	if c.stats != nil {
+		c.stats.reset(c.histograms)
+	}
+
+	// Skip the match finder entirely if the data looks like it does not compress
+	if c.entropyCheck && looksIncompressible(source) {
+		return c.store(source, destination)
+	}
+
+	if c.faults != nil && c.faults.ForceStore {
+		return c.store(source, destination)
+	}
+
+	var e encoder
+	c.beginEncode(&e, source, destination)
+
+	if !c.encodeStep(&e, len(source)) {
+		// Stop the compression and instead store
+		return c.store(source, destination)
+	}
+
+	return c.endEncode(&e)
+}
+
+// The state of the encoder between the steps of encoding a block
+type encoder struct {
+	source      []byte
+	destination []byte
+
+	maxCompressedSize int
+	maxOutputEnd      int // the output may not extend beyond this, either the maximum compressed size or the destination size
+	outputIterator    int
+
+	controlWord        uint
+	controlWordBit     int
+	controlWordPointer int
+
+	// The match located at the next input position
+	nextMatch Match
+
+	// At each position, we select the best match to encode from a list of match candidates provided by the match finder
+	matchCandidates [MAX_MATCH_CANDIDATE_COUNT]Match
+}
+
+// Prepares encoding a block of data
+func (c *Compressor) beginEncode(e *encoder, source []byte, destination []byte) {
+	e.source = source
+	e.destination = destination
 
 	// Compute the maximum output end pointer
 	// We use this to determine whether we should store the data instead of compressing it
-	maxOutputEnd := maxCompressedSize
+	e.maxCompressedSize = c.MaxCompressedSize(len(source))
+	e.maxOutputEnd = e.maxCompressedSize
+	if destination != nil && len(destination) < e.maxOutputEnd {
+		e.maxOutputEnd = len(destination)
+	}
 	// Allocate the header
-	outputIterator := getHeaderSize(maxCompressedSize)
+	e.outputIterator = c.getHeaderSize(e.maxCompressedSize)
 
 	// Initialize the dictionary
-	c.dict.SetBuffer(inputBuffer)
+	c.dict.SetBuffer(source)
 
-	// Initialize the control word which contains the literal/match bits
-	// The highest bit of a control word is a guard bit, which marks the end of the bit list
-	// The guard bit simplifies and speeds up the decoding process, and it
-	const controlWordBitCount int = WORD_SIZE*8 - 1
-	const controlWordGuardBit uint = uint(1) << controlWordBitCount
-	controlWord := controlWordGuardBit
-	controlWordBit := 0
+	e.controlWord = CONTROL_WORD_GUARD_BIT
+	e.controlWordBit = 0
 
 	// Since we do not know the contents of the control words in advance, we allocate space for them and subsequently fill them with data as soon as we can
 	// This is necessary because the decoder must encounter a control word *before* the literals and matches it refers to
 	// We begin the compressed data with a control word
-	controlWordPointer := outputIterator
-	outputIterator += WORD_SIZE
-
-	// The match located at the current inputIterator position
-	var match Match
+	e.controlWordPointer = e.outputIterator
+	e.outputIterator += WORD_SIZE
 
-	// The match located at the next inputIterator position
-	// Initialize it to 'no match', because we are at the beginning of the inputIterator buffer
+	// Initialize the next match to 'no match', because we are at the beginning of the input buffer
 	// A match with a length of 0 means that there is no match
-	var nextMatch Match
-	nextMatch.Length = 0
+	e.nextMatch.Length = 0
 
 	// The dictionary matching look-ahead is 1 character, so set the dictionary position to 1
-	// We don't have to worry about getting matches beyond the inputIterator, because the dictionary ignores such requests
+	// We don't have to worry about getting matches beyond the input, because the dictionary ignores such requests
 	c.dict.Skip()
+}
 
-	// At each position, we select the best match to encode from a list of match candidates provided by the match finder
-	var matchCandidates [MAX_MATCH_CANDIDATE_COUNT]Match
+// Encodes the next maxInputBytes bytes of the block, or slightly more to finish a match
+// Returns false if the output grows too large for the maximum compressed size or the destination, in which case the
+// block must be stored instead
+func (c *Compressor) encodeStep(e *encoder, maxInputBytes int) bool {
+	inputBuffer := e.source
+	outputBuffer := e.destination
+
+	maxOutputEnd := e.maxOutputEnd
+	outputIterator := e.outputIterator
+	controlWord := e.controlWord
+	controlWordBit := e.controlWordBit
+	controlWordPointer := e.controlWordPointer
+
+	// The match located at the current input position
+	var match Match
+
+	// The match located at the next input position
+	nextMatch := e.nextMatch
+
+	matchCandidates := e.matchCandidates[:]
 	var matchCandidateCount int
 
+	inputEnd := len(e.source)
+	if c.dict.Position()-1+maxInputBytes < inputEnd {
+		inputEnd = c.dict.Position() - 1 + maxInputBytes
+	}
+
 	// Iterate while there is still data left
-	for c.dict.Position()-1 < len(source) {
+	for c.dict.Position()-1 < inputEnd {
 		// Check whether the output is too large
 		// During each iteration, we may output up to 8 bytes (2 words), and the compressed stream ends with 4 dummy bytes
 		if outputIterator+2*WORD_SIZE+TRAILING_DUMMY_SIZE > maxOutputEnd {
-			// Stop the compression and instead store
-			return c.store(source, destination)
+			return false
 		}
 
 		// Check whether the control word must be flushed
-		if controlWordBit == controlWordBitCount {
+		if controlWordBit == CONTROL_WORD_BIT_COUNT {
 			// Flush current control word
-			FastWrite(outputBuffer[controlWordPointer:], controlWord, WORD_SIZE)
+			if outputBuffer != nil {
+				FastWrite(outputBuffer[controlWordPointer:], controlWord, WORD_SIZE)
+			}
+
+			if c.tracer != nil {
+				c.tracer.OnControlWord(controlWordPointer, controlWord)
+			}
 
 			// New control word
-			controlWord = controlWordGuardBit
+			controlWord = CONTROL_WORD_GUARD_BIT
 			controlWordBit = 0
 
 			controlWordPointer = outputIterator
@@ -121,12 +265,12 @@ func (c *Compressor) Compress(source []byte, destination []byte) (Result, int) {
 
 		// Find the best match at the next position
 		// The dictionary position is automatically incremented
-		matchCandidateCount = c.dict.FindMatches(matchCandidates[:])
+		matchCandidateCount = c.dict.FindMatches(matchCandidates)
 		nextMatch = c.getBestMatch(matchCandidates[:matchCandidateCount])
 
 		// If we have a match, do not immediately use it, because we may miss an even better match (lazy evaluation)
 		// If encoding a literal and the next match has a higher compression ratio than encoding the current match, discard the current match
-		if match.Length > 0 && (1+nextMatch.Length)*c.getMatchCodedSize(match) > match.Length*(1+c.getMatchCodedSize(nextMatch)) {
+		if match.Length > 0 && (1+nextMatch.Length)*MatchCodedSize(match) > match.Length*(1+MatchCodedSize(nextMatch)) {
 			match.Length = 0
 		}
 
@@ -136,20 +280,44 @@ func (c *Compressor) Compress(source []byte, destination []byte) (Result, int) {
 			// In order to efficiently decode literals in runs, the literal bit (0) must differ from the guard bit (1)
 
 			// The current dictionary position is now two characters ahead of the literal to encode
-			FastWrite(outputBuffer[outputIterator:], uint(inputBuffer[c.dict.Position()-2]), 1)
+			if outputBuffer != nil {
+				FastWrite(outputBuffer[outputIterator:], uint(inputBuffer[c.dict.Position()-2]), 1)
+			}
 			outputIterator++
+
+			if c.stats != nil {
+				c.stats.addLiteral()
+			}
+
+			if c.tracer != nil {
+				c.tracer.OnLiteral(c.dict.Position()-2, inputBuffer[c.dict.Position()-2])
+			}
 		} else {
 			// Encode a match (1 control word flag)
 			controlWord |= uint(1 << controlWordBit)
 
-			outputIterator += c.encodeMatch(match, outputBuffer[outputIterator:])
+			var matchCodedSize int
+			if outputBuffer != nil {
+				matchCodedSize = EncodeMatch(match, outputBuffer[outputIterator:])
+			} else {
+				matchCodedSize = MatchCodedSize(match)
+			}
+			outputIterator += matchCodedSize
+
+			if c.stats != nil {
+				c.stats.addMatch(match, matchCodedSize)
+			}
+
+			if c.tracer != nil {
+				c.tracer.OnMatch(c.dict.Position()-2, match)
+			}
 
 			// Skip the matched characters
 			for i := 0; i < match.Length-2; i++ {
 				c.dict.Skip()
 			}
 
-			matchCandidateCount = c.dict.FindMatches(matchCandidates[:])
+			matchCandidateCount = c.dict.FindMatches(matchCandidates)
 			nextMatch = c.getBestMatch(matchCandidates[:matchCandidateCount])
 		}
 
@@ -157,12 +325,39 @@ func (c *Compressor) Compress(source []byte, destination []byte) (Result, int) {
 		controlWordBit++
 	}
 
+	e.outputIterator = outputIterator
+	e.controlWord = controlWord
+	e.controlWordBit = controlWordBit
+	e.controlWordPointer = controlWordPointer
+	e.nextMatch = nextMatch
+
+	return true
+}
+
+// Checks whether the whole block has been encoded
+func (c *Compressor) encodeDone(e *encoder) bool {
+	return c.dict.Position()-1 >= len(e.source)
+}
+
+// Finishes encoding a block after its last step
+func (c *Compressor) endEncode(e *encoder) (Result, int) {
+	outputBuffer := e.destination
+	outputIterator := e.outputIterator
+
 	// Flush the control word
-	FastWrite(outputBuffer[controlWordPointer:], controlWord, WORD_SIZE)
+	if outputBuffer != nil {
+		FastWrite(outputBuffer[e.controlWordPointer:], e.controlWord, WORD_SIZE)
+	}
+
+	if c.tracer != nil {
+		c.tracer.OnControlWord(e.controlWordPointer, e.controlWord)
+	}
 
 	// Output trailing safety dummy bytes
 	// This reduces the number of necessary buffer checks during decoding
-	FastWrite(outputBuffer[outputIterator:], 0, TRAILING_DUMMY_SIZE)
+	if outputBuffer != nil {
+		FastWrite(outputBuffer[outputIterator:], 0, TRAILING_DUMMY_SIZE)
+	}
 	outputIterator += TRAILING_DUMMY_SIZE
 
 	// Done, compute the compressed size
@@ -172,10 +367,20 @@ func (c *Compressor) Compress(source []byte, destination []byte) (Result, int) {
 	var header Header
 	header.Version = VERSION
 	header.IsStored = false
-	header.UncompressedSize = uint64(len(source))
+	header.UncompressedSize = uint64(len(e.source))
 	header.CompressedSize = uint64(compressedSize)
 
-	c.encodeHeader(header, maxCompressedSize, outputBuffer)
+	if result := c.encodeHeader(header, e.maxCompressedSize, outputBuffer); result != RESULT_OK {
+		return result, 0
+	}
+
+	if c.stats != nil {
+		c.stats.finish(header, c.getHeaderSize(e.maxCompressedSize))
+	}
+
+	if c.tracer != nil {
+		c.tracer.OnBlock(header)
+	}
 
 	// Return the compressed size
 	return RESULT_OK, compressedSize
@@ -186,23 +391,43 @@ func (c *Compressor) store(source []byte, destination []byte) (Result, int) {
 	outputBuffer := destination
 	outputIterator := 0
 
+	if c.logger != nil {
+		c.logger.Debug("doboz: data does not compress, storing it", "size", len(source))
+	}
+
 	// Encode the header
-	maxCompressedSize := GetMaxCompressedSize(len(source))
-	headerSize := getHeaderSize(maxCompressedSize)
+	maxCompressedSize := c.MaxCompressedSize(len(source))
+	headerSize := c.getHeaderSize(maxCompressedSize)
 
 	compressedSize := headerSize + len(source)
 
+	if destination != nil && len(destination) < compressedSize {
+		return RESULT_ERROR_BUFFER_TOO_SMALL, 0
+	}
+
 	var header Header
 	header.Version = VERSION
 	header.IsStored = true
 	header.UncompressedSize = uint64(len(source))
 	header.CompressedSize = uint64(compressedSize)
 
-	c.encodeHeader(header, maxCompressedSize, destination)
+	if result := c.encodeHeader(header, maxCompressedSize, destination); result != RESULT_OK {
+		return result, 0
+	}
 	outputIterator += headerSize
 
 	// Store the data
-	copy(outputBuffer[outputIterator:], source)
+	if outputBuffer != nil {
+		copy(outputBuffer[outputIterator:], source)
+	}
+
+	if c.stats != nil {
+		c.stats.finish(header, headerSize)
+	}
+
+	if c.tracer != nil {
+		c.tracer.OnBlock(header)
+	}
 
 	return RESULT_OK, compressedSize
 }
@@ -212,7 +437,7 @@ func (c *Compressor) getBestMatch(matchCandidates []Match) (bestMatch Match) {
 
 	// Select the longest match which can be coded efficiently (coded size is less than the length)
 	for _, matchCandidate := range matchCandidates {
-		if matchCandidate.Length > c.getMatchCodedSize(matchCandidate) {
+		if matchCandidate.Length > MatchCodedSize(matchCandidate) {
 			bestMatch = matchCandidate
 			break
 		}
@@ -221,46 +446,25 @@ func (c *Compressor) getBestMatch(matchCandidates []Match) (bestMatch Match) {
 	return
 }
 
-func (c *Compressor) encodeMatch(match Match, destination []byte) int {
-	var word uint
-	var size int
-
-	lengthCode := uint(match.Length - MIN_MATCH_LENGTH)
-	offsetCode := uint(match.Offset)
+// Encodes a header, or only checks it if the destination is nil
+// Returns RESULT_ERROR_SIZE_LIMIT_EXCEEDED if the sizes do not fit in the size fields
+func (c *Compressor) encodeHeader(header Header, maxCompressedSize int, destination []byte) Result {
+	sizeCodedSize := uint(c.getSizeCodedSize(maxCompressedSize))
 
-	if lengthCode == 0 && offsetCode < 64 {
-		word = offsetCode << 2 // 00
-		size = 1
-	} else if lengthCode == 0 && offsetCode < 16384 {
-		word = (offsetCode << 2) | 1 // 01
-		size = 2
-	} else if lengthCode < 16 && offsetCode < 1024 {
-		word = (offsetCode << 6) | (lengthCode << 2) | 2 // 10
-		size = 2
-	} else if lengthCode < 32 && offsetCode < 65536 {
-		word = (offsetCode << 8) | (lengthCode << 3) | 3 // 11
-		size = 3
-	} else {
-		word = (offsetCode << 11) | (lengthCode << 3) | 7 // 111
-		size = 4
+	// Check whether the sizes fit, so they are never silently truncated
+	if sizeCodedSize < 8 {
+		limit := uint64(1) << (8 * sizeCodedSize)
+		if header.UncompressedSize >= limit || header.CompressedSize >= limit {
+			return RESULT_ERROR_SIZE_LIMIT_EXCEEDED
+		}
 	}
 
-	if destination != nil {
-		FastWrite(destination, word, size)
+	if destination == nil {
+		return RESULT_OK
 	}
 
-	return size
-}
-
-func (c *Compressor) getMatchCodedSize(match Match) int {
-	return c.encodeMatch(match, nil)
-}
-
-func (c *Compressor) encodeHeader(header Header, maxCompressedSize int, destination []byte) {
 	// Encode the attribute byte
 	attributes := uint(header.Version)
-
-	sizeCodedSize := uint(getSizeCodedSize(maxCompressedSize))
 	attributes |= (sizeCodedSize - 1) << 3
 
 	if header.IsStored {
@@ -288,4 +492,6 @@ func (c *Compressor) encodeHeader(header Header, maxCompressedSize int, destinat
 		binary.LittleEndian.PutUint64(destination, header.UncompressedSize)
 		binary.LittleEndian.PutUint64(destination[8:], header.CompressedSize)
 	}
+
+	return RESULT_OK
 }
@@ -0,0 +1,52 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+import "io"
+
+// Reads the compressed form of the data read from a source, for consumers which pull data, such as HTTP request bodies
+// The data is compressed as a single block, so the whole source is read and compressed on the first read
+type CompressingReader struct {
+	c   *Compressor
+	src io.Reader
+
+	compressed bool
+	data       []byte
+	err        error
+}
+
+// Creates a reader returning the source compressed by a Compressor with the specified options
+// Nothing is read from the source before the first read
+func NewCompressingReader(src io.Reader, options ...CompressorOption) *CompressingReader {
+	return &CompressingReader{c: NewCompressor(options...), src: src}
+}
+
+// Reads compressed data
+// The first call reads the source until io.EOF and compresses it, an empty source yields no data at all
+// Returns the error of the source, or the Result if compression fails
+func (r *CompressingReader) Read(p []byte) (int, error) {
+	if !r.compressed {
+		r.compressed = true
+		r.data, r.err = r.compress()
+	}
+
+	if len(r.data) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func (r *CompressingReader) compress() ([]byte, error) {
+	source, err := io.ReadAll(r.src)
+	if err != nil {
+		return nil, err
+	}
+
+	return compressBuffer(r.c, source)
+}
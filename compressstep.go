@@ -0,0 +1,107 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+// Compresses a block of data in bounded steps, so that callers such as WebAssembly builds in browsers can
+// yield between the steps instead of blocking for the whole block
+// The Compressor must not be used for anything else until the last step is done
+type StepCompressor struct {
+	c *Compressor
+	e encoder
+
+	store          bool // set if the block is stored instead of compressed
+	done           bool
+	compressedSize int
+}
+
+// Prepares compressing a block of data in steps
+// The same rules apply to the source and the destination as for Compress, a destination which turns out to be too
+// small fails the last step with RESULT_ERROR_BUFFER_TOO_SMALL
+// On success, returns RESULT_OK and the StepCompressor
+func (c *Compressor) NewStepCompressor(source []byte, destination []byte) (Result, *StepCompressor) {
+	if len(source) == 0 {
+		return RESULT_ERROR_BUFFER_TOO_SMALL, nil
+	}
+
+	if exceedsMaxSourceSize(len(source)) {
+		return RESULT_ERROR_SIZE_LIMIT_EXCEEDED, nil
+	}
+
+	if destination == nil {
+		return RESULT_ERROR_BUFFER_TOO_SMALL, nil
+	}
+
+	if c.stats != nil {
+		c.stats.reset(c.histograms)
+	}
+
+	s := &StepCompressor{c: c}
+
+	// Data which looks like it does not compress is stored in the first step
+	if (c.entropyCheck && looksIncompressible(source)) || (c.faults != nil && c.faults.ForceStore) {
+		s.e.source = source
+		s.e.destination = destination
+		s.store = true
+		return RESULT_OK, s
+	}
+
+	c.beginEncode(&s.e, source, destination)
+	return RESULT_OK, s
+}
+
+// Compresses about the next maxInputBytes bytes of the block, or slightly more to finish a match
+// Returns RESULT_OK and true once the whole block is compressed, after which CompressedSize returns its size
+// Returns RESULT_ERROR_BUFFER_TOO_SMALL and true if the compressed block does not fit in the destination
+func (s *StepCompressor) CompressStep(maxInputBytes int) (Result, bool) {
+	if s.done {
+		return RESULT_OK, true
+	}
+
+	if !s.store && !s.c.encodeStep(&s.e, max(maxInputBytes, 1)) {
+		// The output is too large, store the block instead
+		s.store = true
+	}
+
+	if s.store {
+		s.done = true
+		s.wipe()
+		var result Result
+		result, s.compressedSize = s.c.store(s.e.source, s.e.destination)
+		return result, true
+	}
+
+	if !s.c.encodeDone(&s.e) {
+		return RESULT_OK, false
+	}
+
+	s.done = true
+	var result Result
+	result, s.compressedSize = s.c.endEncode(&s.e)
+	s.wipe()
+	return result, true
+}
+
+// Clears the state of the compressor after the last step, if it was created with WithCompressWipe
+func (s *StepCompressor) wipe() {
+	if s.c.wipe {
+		s.c.Wipe()
+	}
+}
+
+// Returns the number of input bytes compressed so far and the size of the whole input
+func (s *StepCompressor) Progress() (int, int) {
+	if s.done {
+		return len(s.e.source), len(s.e.source)
+	}
+
+	if s.store {
+		return 0, len(s.e.source)
+	}
+
+	return min(s.c.dict.Position()-1, len(s.e.source)), len(s.e.source)
+}
+
+// Returns the compressed size once the last step is done, or 0 before
+func (s *StepCompressor) CompressedSize() int {
+	return s.compressedSize
+}
@@ -0,0 +1,10 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+// Compresses a string, like Compress
+// The bytes of the string are read in place, without copying them into a byte slice first
+// On success, returns RESULT_OK and outputs the compressed size
+func (c *Compressor) CompressString(source string, destination []byte) (Result, int) {
+	return c.Compress(stringBytes(source), destination)
+}
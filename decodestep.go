@@ -0,0 +1,162 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+import "time"
+
+// The number of bytes DecodeFor decompresses between checking the time
+const DECODE_STEP_CHUNK_SIZE = 16 << 10
+
+// Decompresses a block of data in bounded steps, so that callers such as WebAssembly builds in browsers can
+// yield between the steps instead of blocking for the whole block
+// It runs the same checks as Decompress, but does not report to the statistics or the tracer
+type StepDecoder struct {
+	tokens      *tokenReader
+	destination []byte
+	strict      bool
+
+	isStored bool
+	payload  []byte // the data of a stored block not copied yet
+
+	pending    token // the rest of a token which did not fit in the previous step
+	hasPending bool
+	produced   int
+
+	done   bool
+	result Result
+}
+
+// Prepares decompressing a block of data in steps
+// The same rules apply to the source and the destination as for Decompress
+// On success, returns RESULT_OK and the StepDecoder
+func (d *Decompressor) NewStepDecoder(source []byte, destination []byte) (Result, *StepDecoder) {
+	// Decode the header
+	decodeHeaderResult, header, headerSize := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK {
+		return decodeHeaderResult, nil
+	}
+
+	// Check whether the supplied buffers are large enough
+	if uint64(len(source)) < header.CompressedSize || uint64(len(destination)) < header.UncompressedSize {
+		return RESULT_ERROR_BUFFER_TOO_SMALL, nil
+	}
+
+	// In strict mode the supplied buffers must match the header exactly
+	if d.strict {
+		if uint64(len(source)) != header.CompressedSize {
+			return RESULT_ERROR_TRAILING_DATA, nil
+		}
+
+		if uint64(len(destination)) != header.UncompressedSize {
+			return RESULT_ERROR_OUTPUT_SIZE_MISMATCH, nil
+		}
+	}
+
+	s := &StepDecoder{
+		tokens:      d.newTokenReader(source, header, headerSize),
+		destination: destination,
+		strict:      d.strict,
+	}
+
+	// Stored data is copied like Decompress does, as much of it as the source holds
+	if header.IsStored {
+		if d.strict && header.CompressedSize != uint64(headerSize)+header.UncompressedSize {
+			return RESULT_ERROR_STREAM_SIZE_MISMATCH, nil
+		}
+
+		s.isStored = true
+		s.payload = source[headerSize:min(len(source), headerSize+int(header.UncompressedSize))]
+	}
+
+	return RESULT_OK, s
+}
+
+// Decompresses at most the next maxOutputBytes bytes of the block, resuming where the previous step stopped
+// Returns RESULT_OK and true once the whole block is decompressed, or the error and true if decoding fails
+func (s *StepDecoder) DecodeStep(maxOutputBytes int) (Result, bool) {
+	if s.done {
+		return s.result, true
+	}
+
+	budget := max(maxOutputBytes, 1)
+
+	if s.isStored {
+		n := copy(s.destination[s.produced:], s.payload[:min(budget, len(s.payload))])
+		s.payload = s.payload[n:]
+		s.produced += n
+
+		if len(s.payload) == 0 {
+			return s.finish(RESULT_OK)
+		}
+
+		return RESULT_OK, false
+	}
+
+	for budget > 0 {
+		if !s.hasPending {
+			result, tok, ok := s.tokens.next()
+			if result != RESULT_OK {
+				return s.finish(result)
+			}
+
+			if !ok {
+				if s.strict && !s.tokens.endsExactly() {
+					return s.finish(RESULT_ERROR_STREAM_SIZE_MISMATCH)
+				}
+
+				return s.finish(RESULT_OK)
+			}
+
+			s.pending = tok
+			s.hasPending = true
+		}
+
+		tok := s.pending
+
+		// Tokens which do not fit in the budget are split over the steps
+		if tok.length > budget {
+			tok.length = budget
+
+			if !tok.isMatch {
+				s.pending.inputPosition += budget
+			}
+			s.pending.outputPosition += budget
+			s.pending.length -= budget
+		} else {
+			s.hasPending = false
+		}
+
+		s.tokens.copyToken(tok, s.destination)
+		s.produced += tok.length
+		budget -= tok.length
+	}
+
+	return RESULT_OK, false
+}
+
+// Decompresses the block in chunks of DECODE_STEP_CHUNK_SIZE bytes until the time budget is used up
+// The time is only checked between the chunks, so a step may take a little longer than the budget, and it always
+// decompresses at least one chunk
+// Returns RESULT_OK and true once the whole block is decompressed, or the error and true if decoding fails
+func (s *StepDecoder) DecodeFor(budget time.Duration) (Result, bool) {
+	start := time.Now()
+
+	for {
+		result, done := s.DecodeStep(DECODE_STEP_CHUNK_SIZE)
+		if done || time.Since(start) >= budget {
+			return result, done
+		}
+	}
+}
+
+// Returns the number of bytes decompressed so far and the uncompressed size of the block
+func (s *StepDecoder) Progress() (int, int) {
+	return s.produced, s.tokens.outputEnd
+}
+
+func (s *StepDecoder) finish(result Result) (Result, bool) {
+	s.done = true
+	s.result = result
+	return result, true
+}
@@ -0,0 +1,48 @@
+//go:build !dobozdecodeonly && !dobozencodeonly && !tinygo
+
+package doboz
+
+import (
+	"context"
+	"log/slog"
+	"runtime/pprof"
+	"time"
+)
+
+// Decompresses with logging and profiler labels, see WithDecodeLogger and WithDecodeProfileLabels
+func (d *Decompressor) decompressInstrumented(source []byte, destination []byte) (result Result) {
+	start := time.Now()
+
+	if d.profile {
+		labels := profileLabels("decompress", len(source), d.profileLabels)
+		pprof.Do(context.Background(), labels, func(context.Context) {
+			result = d.decompress(source, destination)
+		})
+	} else {
+		result = d.decompress(source, destination)
+	}
+
+	if d.logger != nil {
+		d.logDecompress(source, result, time.Since(start))
+	}
+
+	return result
+}
+
+func (d *Decompressor) logDecompress(source []byte, result Result, duration time.Duration) {
+	if result != RESULT_OK {
+		d.logger.LogAttrs(context.Background(), slog.LevelDebug, "doboz: decompression failed",
+			slog.Int("sourceSize", len(source)),
+			slog.String("error", result.Error()))
+		return
+	}
+
+	// The header has already been validated
+	_, header, _ := d.decodeHeader(source)
+
+	d.logger.LogAttrs(context.Background(), slog.LevelDebug, "doboz: decompressed block",
+		slog.Uint64("uncompressedSize", header.UncompressedSize),
+		slog.Uint64("compressedSize", header.CompressedSize),
+		slog.Bool("stored", header.IsStored),
+		slog.Duration("duration", duration))
+}
@@ -0,0 +1,9 @@
+//go:build !dobozencodeonly && (dobozdecodeonly || tinygo)
+
+package doboz
+
+// Decode-only and TinyGo builds leave out the logging and profiling machinery,
+// so WithDecodeLogger and WithDecodeProfileLabels have no effect
+func (d *Decompressor) decompressInstrumented(source []byte, destination []byte) Result {
+	return d.decompress(source, destination)
+}
@@ -0,0 +1,116 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+import "log/slog"
+
+// Configures a Decompressor created with NewDecompressor
+type DecompressorOption func(*Decompressor)
+
+// Creates a new Decompressor with the specified options applied
+// A zero value Decompressor is also ready to use and has no limits set
+func NewDecompressor(options ...DecompressorOption) *Decompressor {
+	d := new(Decompressor)
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// Limits the uncompressed size a block may declare in its header
+// Blocks exceeding the limit are rejected with RESULT_ERROR_SIZE_LIMIT_EXCEEDED before any data is decoded,
+// which protects callers allocating destination buffers based on GetCompressionInfo from decompression bombs
+// A limit of 0 means no limit
+func WithMaxDecodedSize(maxDecodedSize uint64) DecompressorOption {
+	return func(d *Decompressor) {
+		d.maxDecodedSize = maxDecodedSize
+	}
+}
+
+// Limits the compressed size a block may declare in its header
+// Blocks exceeding the limit are rejected with RESULT_ERROR_SIZE_LIMIT_EXCEEDED before any data is decoded
+// A limit of 0 means no limit
+func WithMaxBlockSize(maxBlockSize uint64) DecompressorOption {
+	return func(d *Decompressor) {
+		d.maxBlockSize = maxBlockSize
+	}
+}
+
+// Limits how far back matches may refer to, so that DecompressTo keeps a smaller window of recent output
+// Blocks containing a match with a larger offset are rejected with RESULT_ERROR_SIZE_LIMIT_EXCEEDED when the match is
+// reached, the compressor only produces such matches within MATCH_WINDOW_SIZE bytes
+// A limit of 0 means no limit, DICTIONARY_SIZE or above has no effect
+func WithMaxWindowSize(maxWindowSize int) DecompressorOption {
+	return func(d *Decompressor) {
+		d.maxWindowSize = max(maxWindowSize, 0)
+	}
+}
+
+// Enables strict validation of the compressed stream against its header
+// Decompress then fails with RESULT_ERROR_TRAILING_DATA if the source is longer than the compressed size,
+// with RESULT_ERROR_OUTPUT_SIZE_MISMATCH if the destination length differs from the uncompressed size,
+// and with RESULT_ERROR_STREAM_SIZE_MISMATCH if decoding does not end exactly at the compressed size
+func WithStrictValidation() DecompressorOption {
+	return func(d *Decompressor) {
+		d.strict = true
+	}
+}
+
+// Attempts to decode blocks of unknown format versions newer than VERSION as if they were VERSION
+// By default such blocks are rejected with RESULT_ERROR_UNSUPPORTED_VERSION
+// This allows readers to keep working while a compatible format revision is rolled out
+func WithNewerVersions() DecompressorOption {
+	return func(d *Decompressor) {
+		d.newerVersions = true
+	}
+}
+
+// Accumulates decoding statistics into the supplied structure during every Decompress call
+// Without this option no statistics are gathered
+func WithDecodeStats(stats *DecodeStats) DecompressorOption {
+	return func(d *Decompressor) {
+		d.stats = stats
+	}
+}
+
+// Reports every literal, match, control word and block decoded by Decompress to the tracer
+func WithDecodeTracer(tracer Tracer) DecompressorOption {
+	return func(d *Decompressor) {
+		d.tracer = tracer
+	}
+}
+
+// Logs decompressed blocks, decoding failures and their timing to the logger at Debug level
+// Has no effect in builds with the dobozdecodeonly tag and under TinyGo
+func WithDecodeLogger(logger *slog.Logger) DecompressorOption {
+	return func(d *Decompressor) {
+		d.logger = logger
+	}
+}
+
+// Runs Decompress with runtime/pprof labels for the operation and the input size bucket, so CPU profiles attribute the time to doboz
+// The optional labels are additional key-value pairs, such as the name of the call site, and their count must be even
+// Has no effect in builds with the dobozdecodeonly tag and under TinyGo
+func WithDecodeProfileLabels(labels ...string) DecompressorOption {
+	return func(d *Decompressor) {
+		d.profile = true
+		d.profileLabels = labels
+	}
+}
+
+// Clears the internal buffers holding decoded or compressed data, such as the window of DecompressTo and the padded
+// input of Salvage, before returning
+// Decompress itself keeps no copy of the data, so this only affects the functions using internal buffers
+func WithDecodeWipe() DecompressorOption {
+	return func(d *Decompressor) {
+		d.wipe = true
+	}
+}
+
+// Injects the supplied faults into every Decompress call, see Faults
+// Meant for tests only
+func WithDecodeFaults(faults *Faults) DecompressorOption {
+	return func(d *Decompressor) {
+		d.faults = faults
+	}
+}
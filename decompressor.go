@@ -1,79 +1,123 @@
+//go:build !dobozencodeonly
+
 package doboz
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+	"log/slog"
+)
 
 type CompressionInfo struct {
 	UncompressedSize uint64
 	CompressedSize   uint64
 	Version          int
+	IsStored         bool // set if the data is stored without compression
+	HeaderSize       int  // the size of the header in bytes, the compressed data follows it
 }
 
-type LookupTable struct {
-	mask        uint // the mask for the entire encoded match
-	offsetShift byte
-	lengthMask  byte
-	lengthShift byte
-	size        int8 // the size of the encoded match in bytes
-}
+// The number of literals in a run, looked up by the lowest 4 bits of the control word
+var literalRunLengthTable = [16]int8{4, 0, 1, 0, 2, 0, 1, 0, 3, 0, 1, 0, 2, 0, 1, 0}
 
+// Decompresses doboz blocks, checking them against the configured limits
+// Decoding does not modify the Decompressor, so several goroutines can use it at once, unless it reports to
+// statistics set with WithDecodeStats, which are updated without synchronization
 type Decompressor struct {
-	literalRunLengthTable []int8
-	lut                   []LookupTable
-}
-
-func (d *Decompressor) initialize() {
-	d.literalRunLengthTable = []int8{4, 0, 1, 0, 2, 0, 1, 0, 3, 0, 1, 0, 2, 0, 1, 0}
-	d.lut = []LookupTable{
-		{mask: 0xff, offsetShift: 2, lengthMask: 0, lengthShift: 0, size: 1},          // (0)00
-		{mask: 0xffff, offsetShift: 2, lengthMask: 0, lengthShift: 0, size: 2},        // (0)01
-		{mask: 0xffff, offsetShift: 6, lengthMask: 15, lengthShift: 2, size: 2},       // (0)10
-		{mask: 0xffffff, offsetShift: 8, lengthMask: 31, lengthShift: 3, size: 3},     // (0)11
-		{mask: 0xff, offsetShift: 2, lengthMask: 0, lengthShift: 0, size: 1},          // (1)00 = (0)00
-		{mask: 0xffff, offsetShift: 2, lengthMask: 0, lengthShift: 0, size: 2},        // (1)01 = (0)01
-		{mask: 0xffff, offsetShift: 6, lengthMask: 15, lengthShift: 2, size: 2},       // (1)10 = (0)10
-		{mask: 0xffffffff, offsetShift: 11, lengthMask: 255, lengthShift: 3, size: 4}, // 111
-	}
+	maxDecodedSize uint64 // 0 means unlimited
+	maxBlockSize   uint64 // 0 means unlimited
+	maxWindowSize  int    // 0 means unlimited
+	strict         bool
+	newerVersions  bool
+	wipe           bool
+	stats          *DecodeStats
+	tracer         Tracer
+	logger         *slog.Logger
+	faults         *Faults
+
+	profile       bool
+	profileLabels []string
 }
 
 // Decompresses a block of data
-// The source and destination buffers must not overlap
+// The source and destination buffers must not overlap, except as arranged by DecompressInPlace
 // This operation is memory safe
 // On success, returns RESULT_OK
 func (d *Decompressor) Decompress(source []byte, destination []byte) Result {
-	d.initialize()
+	if d.logger == nil && !d.profile {
+		return d.decompress(source, destination)
+	}
 
-	inputBuffer := source
-	inputIterator := 0
+	return d.decompressInstrumented(source, destination)
+}
 
-	outputBuffer := destination
-	outputIterator := 0
+func (d *Decompressor) decompress(source []byte, destination []byte) Result {
+	if d.faults != nil {
+		source, destination = d.faults.apply(source, destination)
+	}
 
 	// Decode the header
-	decodeHeaderResult, header, headerSize := d.decodeHeader(source)
+	decodeHeaderResult, header, headerSize := d.decodeBlockHeader(source)
 
 	if decodeHeaderResult != RESULT_OK {
 		return decodeHeaderResult
 	}
 
-	inputIterator += headerSize
-
-	if header.Version != VERSION {
-		return RESULT_ERROR_UNSUPPORTED_VERSION
-	}
-
 	// Check whether the supplied buffers are large enough
 	if uint64(len(source)) < header.CompressedSize || uint64(len(destination)) < header.UncompressedSize {
 		return RESULT_ERROR_BUFFER_TOO_SMALL
 	}
 
-	uncompressedSize := int(header.UncompressedSize)
+	// In strict mode the supplied buffers must match the header exactly
+	if d.strict {
+		if uint64(len(source)) != header.CompressedSize {
+			return RESULT_ERROR_TRAILING_DATA
+		}
+
+		if uint64(len(destination)) != header.UncompressedSize {
+			return RESULT_ERROR_OUTPUT_SIZE_MISMATCH
+		}
+	}
+
+	if d.tracer != nil {
+		d.tracer.OnBlock(header)
+	}
 
 	// If the data is simply stored, copy it to the destination buffer and we're done
 	if header.IsStored {
-		copy(outputBuffer[:uncompressedSize], inputBuffer[inputIterator:])
+		if d.strict && header.CompressedSize != uint64(headerSize)+header.UncompressedSize {
+			return RESULT_ERROR_STREAM_SIZE_MISMATCH
+		}
+
+		copy(destination[:header.UncompressedSize], source[headerSize:])
+
+		if d.stats != nil {
+			d.stats.StoredBlockCount++
+			d.stats.LiteralCount += int(header.UncompressedSize)
+		}
+
 		return RESULT_OK
 	}
 
+	// Decode the data with the implementation of its format version
+	decoder, _ := d.versionDecoder(header.Version)
+	decodeResult := decoder(d, source, destination, header, headerSize)
+
+	if d.stats != nil && decodeResult == RESULT_OK {
+		d.stats.BlockCount++
+	}
+
+	return decodeResult
+}
+
+// Decodes the data of a compressed block with format version 0
+// The header has already been checked against the buffers
+func (d *Decompressor) decompressVersion0(source []byte, destination []byte, header Header, headerSize int) Result {
+	inputBuffer := source
+	inputIterator := headerSize
+
+	outputBuffer := destination
+	outputIterator := 0
+
+	uncompressedSize := int(header.UncompressedSize)
 	inputEnd := int(header.CompressedSize)
 	outputEnd := uncompressedSize
 
@@ -100,6 +144,14 @@ func (d *Decompressor) Decompress(source []byte, destination []byte) Result {
 		if controlWord == 1 {
 			controlWord = FastRead(inputBuffer[inputIterator:], WORD_SIZE)
 			inputIterator += WORD_SIZE
+
+			if d.stats != nil {
+				d.stats.ControlWordCount++
+			}
+
+			if d.tracer != nil {
+				d.tracer.OnControlWord(inputIterator-WORD_SIZE, controlWord)
+			}
 		}
 
 		// Detect whether it's a literal or a match
@@ -114,7 +166,7 @@ func (d *Decompressor) Decompress(source []byte, destination []byte) Result {
 				FastWrite(outputBuffer[outputIterator:], FastRead(inputBuffer[inputIterator:], WORD_SIZE), WORD_SIZE)
 
 				// Get the run length using a lookup table
-				runLength := int(d.literalRunLengthTable[controlWord&0xf])
+				runLength := int(literalRunLengthTable[controlWord&0xf])
 
 				// Advance the inputBuffer and outputBuffer pointers with the run length
 				inputIterator += runLength
@@ -122,6 +174,16 @@ func (d *Decompressor) Decompress(source []byte, destination []byte) Result {
 
 				// Consume as much control word bits as the run length
 				controlWord >>= runLength
+
+				if d.stats != nil {
+					d.stats.LiteralCount += runLength
+				}
+
+				if d.tracer != nil {
+					for i := runLength; i > 0; i-- {
+						d.tracer.OnLiteral(outputIterator-i, outputBuffer[outputIterator-i])
+					}
+				}
 			} else {
 				// We have reached the tail, we cannot output literals in runs anymore
 				// Output all remaining literals
@@ -136,6 +198,14 @@ func (d *Decompressor) Decompress(source []byte, destination []byte) Result {
 					if controlWord == 1 {
 						controlWord = FastRead(inputBuffer[inputIterator:], WORD_SIZE)
 						inputIterator += WORD_SIZE
+
+						if d.stats != nil {
+							d.stats.ControlWordCount++
+						}
+
+						if d.tracer != nil {
+							d.tracer.OnControlWord(inputIterator-WORD_SIZE, controlWord)
+						}
 					}
 
 					// Output one literal
@@ -146,6 +216,20 @@ func (d *Decompressor) Decompress(source []byte, destination []byte) Result {
 
 					// Next control word bit
 					controlWord >>= 1
+
+					if d.stats != nil {
+						d.stats.LiteralCount++
+					}
+
+					if d.tracer != nil {
+						d.tracer.OnLiteral(outputIterator-1, outputBuffer[outputIterator-1])
+					}
+				}
+
+				// In strict mode only the trailing dummy bytes may follow the last literal,
+				// and the unused bits of the last control word must be zero
+				if d.strict && (inputIterator+TRAILING_DUMMY_SIZE != inputEnd || controlWord&(controlWord-1) != 0) {
+					return RESULT_ERROR_STREAM_SIZE_MISMATCH
 				}
 
 				// Done
@@ -168,6 +252,10 @@ func (d *Decompressor) Decompress(source []byte, destination []byte) Result {
 				return RESULT_ERROR_CORRUPTED_DATA
 			}
 
+			if d.exceedsMaxWindowSize(match) {
+				return RESULT_ERROR_SIZE_LIMIT_EXCEEDED
+			}
+
 			i := 0
 
 			if match.Offset < WORD_SIZE {
@@ -193,6 +281,14 @@ func (d *Decompressor) Decompress(source []byte, destination []byte) Result {
 
 			outputIterator += match.Length
 
+			if d.stats != nil {
+				d.stats.addMatch(match)
+			}
+
+			if d.tracer != nil {
+				d.tracer.OnMatch(outputIterator-match.Length, match)
+			}
+
 			// Next control word bit
 			controlWord >>= 1
 		}
@@ -206,34 +302,105 @@ func (d *Decompressor) GetCompressionInfo(source []byte) (Result, CompressionInf
 	var compressionInfo CompressionInfo
 
 	// Decode the header
-	decodeHeaderResult, header, _ := d.decodeHeader(source)
+	decodeHeaderResult, header, headerSize := d.decodeHeader(source)
 
 	if decodeHeaderResult != RESULT_OK {
 		return decodeHeaderResult, compressionInfo
 	}
 
+	// Reject the block before the caller allocates a buffer for it
+	if d.exceedsLimits(header) {
+		return RESULT_ERROR_SIZE_LIMIT_EXCEEDED, compressionInfo
+	}
+
 	// Return the requested info
 	compressionInfo.UncompressedSize = header.UncompressedSize
 	compressionInfo.CompressedSize = header.CompressedSize
 	compressionInfo.Version = header.Version
+	compressionInfo.IsStored = header.IsStored
+	compressionInfo.HeaderSize = headerSize
 
 	return RESULT_OK, compressionInfo
 }
 
-// Decodes a match and returns its size in bytes
-func (d *Decompressor) decodeMatch(source []byte) (Match, int) {
-	// Read the maximum number of bytes a match is coded in (4)
-	word := FastRead(source, WORD_SIZE)
+// Decodes a header and checks whether the block it describes can be decoded
+func (d *Decompressor) decodeBlockHeader(source []byte) (Result, Header, int) {
+	decodeHeaderResult, header, headerSize := d.decodeHeader(source)
 
-	// Compute the decoding lookup table entry index: the lowest 3 bits of the encoded match
-	i := word & 7
+	if decodeHeaderResult != RESULT_OK {
+		return decodeHeaderResult, header, headerSize
+	}
 
-	// Compute the match offset and length using the lookup table entry
-	var match Match
-	match.Offset = (int)((word & d.lut[i].mask) >> d.lut[i].offsetShift)
-	match.Length = (int)(((word >> uint(d.lut[i].lengthShift)) & uint(d.lut[i].lengthMask)) + MIN_MATCH_LENGTH)
+	if _, ok := d.versionDecoder(header.Version); !ok {
+		return RESULT_ERROR_UNSUPPORTED_VERSION, header, headerSize
+	}
+
+	// Check whether the block is allowed to be this large
+	if d.exceedsLimits(header) {
+		return RESULT_ERROR_SIZE_LIMIT_EXCEEDED, header, headerSize
+	}
 
-	return match, int(d.lut[i].size)
+	return RESULT_OK, header, headerSize
+}
+
+// Returns the payload of a stored block as a subslice of the source, without copying it
+// Returns false if the block is not stored or its header is not valid
+// This operation is memory safe
+func (d *Decompressor) StoredPayload(source []byte) ([]byte, bool) {
+	// Decode the header
+	decodeHeaderResult, header, headerSize := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK || !header.IsStored {
+		return nil, false
+	}
+
+	// The payload must be entirely inside the source
+	payloadEnd := uint64(headerSize) + header.UncompressedSize
+	if payloadEnd < header.UncompressedSize || payloadEnd > header.CompressedSize || payloadEnd > uint64(len(source)) {
+		return nil, false
+	}
+
+	return source[headerSize:payloadEnd], true
+}
+
+// Checks whether the sizes declared in the header are above the configured limits
+// A compressed size above the maximum compressed size of the uncompressed size is always above the limit
+// Sizes which do not fit in an int, such as sizes above 2 GB on 32-bit platforms, can never be decoded and are always above the limit
+func (d *Decompressor) exceedsLimits(header Header) bool {
+	if header.UncompressedSize > uint64(MaxInt) || header.CompressedSize > uint64(MaxInt) {
+		return true
+	}
+
+	// No compressor produces a block larger than the data stored with a header of 8-byte size fields, so a larger
+	// compressed size is never allocated for
+	if header.CompressedSize > header.UncompressedSize+wideHeaderSize {
+		return true
+	}
+
+	if d.maxBlockSize != 0 && header.CompressedSize > d.maxBlockSize {
+		return true
+	}
+
+	return d.maxDecodedSize != 0 && header.UncompressedSize > d.maxDecodedSize
+}
+
+// Checks whether a match reaches further back than the configured window size
+func (d *Decompressor) exceedsMaxWindowSize(match Match) bool {
+	return d.maxWindowSize != 0 && match.Offset > d.maxWindowSize
+}
+
+// Returns the number of bytes of recent output matches may refer to, which DecompressTo has to keep
+func (d *Decompressor) windowSize() int {
+	if d.maxWindowSize != 0 {
+		return min(d.maxWindowSize, DICTIONARY_SIZE)
+	}
+
+	return DICTIONARY_SIZE
+}
+
+// Decodes a match and returns its size in bytes
+func (d *Decompressor) decodeMatch(source []byte) (Match, int) {
+	return DecodeMatch(source)
 }
 
 // Decodes a header and returns its size in bytes
@@ -247,7 +414,6 @@ func (d *Decompressor) decodeHeader(source []byte) (Result, Header, int) {
 	}
 
 	attributes := uint(source[0])
-	source = source[1:]
 
 	header.Version = int(attributes & 7)
 	sizeCodedSize := int((attributes>>3)&7) + 1
@@ -259,6 +425,8 @@ func (d *Decompressor) decodeHeader(source []byte) (Result, Header, int) {
 		return RESULT_ERROR_BUFFER_TOO_SMALL, header, headerSize
 	}
 
+	source = source[1:]
+
 	header.IsStored = (attributes & 128) != 0
 
 	// Decode the uncompressed and compressed sizes
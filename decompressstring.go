@@ -0,0 +1,29 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+// Decompresses a block of data into a new string
+// The string is allocated with the uncompressed size from the header, which is checked against the limits of the
+// decompressor first, and the data is decompressed into it without an extra copy
+// This operation is memory safe
+// On success, returns RESULT_OK and the decompressed string
+func (d *Decompressor) DecompressToString(source []byte) (Result, string) {
+	// Decode the header
+	decodeHeaderResult, header, _ := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK {
+		return decodeHeaderResult, ""
+	}
+
+	// Check whether the supplied buffer is large enough before allocating the string
+	if uint64(len(source)) < header.CompressedSize {
+		return RESULT_ERROR_BUFFER_TOO_SMALL, ""
+	}
+
+	destination := make([]byte, header.UncompressedSize)
+	if result := d.Decompress(source, destination); result != RESULT_OK {
+		return result, ""
+	}
+
+	return RESULT_OK, bytesString(destination)
+}
@@ -1,10 +1,11 @@
+//go:build !dobozdecodeonly
+
 package doboz
 
 const (
-	HASH_TABLE_SIZE  = 1 << 20
-	CHILD_COUNT      = DICTIONARY_SIZE * 2
+	CHILD_COUNT      = MATCH_WINDOW_SIZE * 2
 	INVALID_POSITION = -1
-	REBASE_THRESHOLD = (MaxInt - DICTIONARY_SIZE + 1) / DICTIONARY_SIZE * DICTIONARY_SIZE // must be a multiple of DICTIONARY_SIZE!
+	REBASE_THRESHOLD = (MaxInt - MATCH_WINDOW_SIZE + 1) / MATCH_WINDOW_SIZE * MATCH_WINDOW_SIZE // must be a multiple of MATCH_WINDOW_SIZE!
 )
 
 type Dictionary struct {
@@ -70,8 +71,8 @@ func (d *Dictionary) FindMatches(matchCandidates []Match) int {
 
 	// Compute the minimum match position
 	minMatchPosition := 0
-	if position >= DICTIONARY_SIZE {
-		minMatchPosition = position - DICTIONARY_SIZE + 1
+	if position >= MATCH_WINDOW_SIZE {
+		minMatchPosition = position - MATCH_WINDOW_SIZE + 1
 	}
 
 	// Compute the hash value for the current string
@@ -84,7 +85,7 @@ func (d *Dictionary) FindMatches(matchCandidates []Match) int {
 	d.hashTable[hashValue] = position
 
 	// Compute the current cyclic position in the dictionary
-	cyclicInputPosition := position % DICTIONARY_SIZE
+	cyclicInputPosition := position % MATCH_WINDOW_SIZE
 
 	// Initialize the references to the leaves of the new root's left and right subtrees
 	leftSubtreeLeaf := cyclicInputPosition * 2
@@ -120,7 +121,7 @@ func (d *Dictionary) FindMatches(matchCandidates []Match) int {
 		matchCount++
 
 		// Compute the cyclic position of the current match in the dictionary
-		cyclicMatchPosition := matchPosition % DICTIONARY_SIZE
+		cyclicMatchPosition := matchPosition % MATCH_WINDOW_SIZE
 
 		// Use the match lengths of the low and high bounds to determine the number of characters that surely match
 		matchLength := min(lowMatchLength, highMatchLength)
@@ -200,6 +201,18 @@ func (d *Dictionary) initialize() {
 	d.children = make([]int, CHILD_COUNT)
 }
 
+// Clears the hash table and the binary trees, and releases the buffer
+// The positions stored in them reveal which parts of the buffer repeat, so they are cleared for sensitive data
+func (d *Dictionary) Wipe() {
+	clear(d.hashTable)
+	clear(d.children)
+
+	d.buffer = nil
+	d.bufferBase = 0
+	d.matchableBufferLength = 0
+	d.absolutePosition = 0
+}
+
 // Increments the match window position with one character
 func (d *Dictionary) computeRelativePosition() int {
 	position := d.absolutePosition - d.bufferBase
@@ -207,7 +220,7 @@ func (d *Dictionary) computeRelativePosition() int {
 	// Check whether the current position has reached the rebase threshold
 	if position == REBASE_THRESHOLD {
 		// Rebase
-		rebaseDelta := REBASE_THRESHOLD - DICTIONARY_SIZE
+		rebaseDelta := REBASE_THRESHOLD - MATCH_WINDOW_SIZE
 
 		d.bufferBase += rebaseDelta
 		position -= rebaseDelta
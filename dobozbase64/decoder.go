@@ -0,0 +1,107 @@
+//go:build !dobozencodeonly
+
+package dobozbase64
+
+import (
+	"encoding/base64"
+	"io"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Reads base64 text holding a doboz block, as written by Encoder, and returns the decompressed data
+type Decoder struct {
+	decompressor *doboz.Decompressor
+	r            io.Reader
+
+	decoded bool
+	data    []byte
+	err     error
+}
+
+// Creates a decoder reading base64 text with the specified encoding from r
+// A nil decompressor decompresses with the default settings, use one created with doboz.WithMaxDecodedSize to limit
+// the memory an untrusted block can claim
+func NewDecoder(encoding *base64.Encoding, decompressor *doboz.Decompressor, r io.Reader) *Decoder {
+	if decompressor == nil {
+		decompressor = new(doboz.Decompressor)
+	}
+
+	return &Decoder{decompressor: decompressor, r: base64.NewDecoder(encoding, r)}
+}
+
+// Reads decompressed data
+// The whole block is read and decompressed on the first call
+// Returns the Result if the block cannot be decompressed, doboz.RESULT_ERROR_TRUNCATED if the text ends within
+// the block, or the error of the underlying reader
+func (d *Decoder) Read(p []byte) (int, error) {
+	if !d.decoded {
+		d.decoded = true
+		d.data, d.err = d.decode()
+	}
+
+	if len(d.data) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		return 0, io.EOF
+	}
+
+	n := copy(p, d.data)
+	d.data = d.data[n:]
+	return n, nil
+}
+
+// Reads exactly the compressed block and decompresses it
+// The size of the block is taken from its header, so the decoder never reads beyond the block
+func (d *Decoder) decode() ([]byte, error) {
+	// The attribute byte tells the size of the header
+	attributes := make([]byte, 1)
+	if _, err := io.ReadFull(d.r, attributes); err != nil {
+		// No text at all decodes to no data
+		return nil, err
+	}
+
+	headerSize := 1 + 2*(int(attributes[0]>>3&7)+1)
+
+	header := make([]byte, headerSize)
+	header[0] = attributes[0]
+	if err := d.readFull(header[1:]); err != nil {
+		return nil, err
+	}
+
+	// The header is checked against the limits of the decompressor before anything is allocated for the block
+	result, info := d.decompressor.GetCompressionInfo(header)
+	if result != doboz.RESULT_OK {
+		return nil, result
+	}
+
+	if info.CompressedSize < uint64(headerSize) {
+		return nil, doboz.RESULT_ERROR_CORRUPTED_DATA
+	}
+
+	// The compressed block is dropped afterwards, so clear it in case the data is sensitive
+	compressed := make([]byte, info.CompressedSize)
+	defer clear(compressed)
+	copy(compressed, header)
+	if err := d.readFull(compressed[headerSize:]); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, info.UncompressedSize)
+	if result := d.decompressor.Decompress(compressed, data); result != doboz.RESULT_OK {
+		return nil, result
+	}
+
+	return data, nil
+}
+
+// Fills p from the text, turning a premature end into doboz.RESULT_ERROR_TRUNCATED
+func (d *Decoder) readFull(p []byte) error {
+	_, err := io.ReadFull(d.r, p)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return doboz.RESULT_ERROR_TRUNCATED
+	}
+
+	return err
+}
@@ -0,0 +1,7 @@
+// Package dobozbase64 chains doboz compression with base64, so compressed payloads can be embedded in text such as
+// JSON, YAML or environment variables
+//
+// The text holds a single doboz block encoded with any base64.Encoding
+// A block must be compressed as a whole, so the Encoder keeps the written data in memory until it is closed, and the
+// Decoder decompresses the whole block on the first read
+package dobozbase64
@@ -0,0 +1,82 @@
+//go:build !dobozdecodeonly
+
+package dobozbase64
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+var errClosed = errors.New("dobozbase64: write to closed encoder")
+
+// Compresses the data written to it and writes the compressed block as base64 text when closed
+type Encoder struct {
+	encoding   *base64.Encoding
+	compressor *doboz.Compressor
+	w          io.Writer
+
+	data   []byte
+	closed bool
+}
+
+// Creates an encoder writing base64 text with the specified encoding to w
+// A nil compressor compresses with the default settings
+func NewEncoder(encoding *base64.Encoding, compressor *doboz.Compressor, w io.Writer) *Encoder {
+	if compressor == nil {
+		compressor = new(doboz.Compressor)
+	}
+
+	return &Encoder{encoding: encoding, compressor: compressor, w: w}
+}
+
+// Adds p to the data to compress
+// Nothing is written to the underlying writer before Close
+func (e *Encoder) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errClosed
+	}
+
+	e.data = append(e.data, p...)
+	return len(p), nil
+}
+
+// Compresses the written data and writes it to the underlying writer as base64 text, including the final padding
+// Writing no data at all produces no text, which decodes to no data
+// The underlying writer is not closed
+// Returns the Result if compression fails, or the error of the writer
+func (e *Encoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	data := e.data
+	e.data = nil
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	// The buffers are dropped afterwards, so clear them in case the data is sensitive
+	var compressed bytes.Buffer
+	defer func() {
+		block := compressed.Bytes()
+		clear(block[:cap(block)])
+	}()
+	defer clear(data)
+
+	if _, err := e.compressor.CompressInto(data, &compressed); err != nil {
+		return err
+	}
+
+	w := base64.NewEncoder(e.encoding, e.w)
+	if _, err := w.Write(compressed.Bytes()); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
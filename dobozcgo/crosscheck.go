@@ -0,0 +1,85 @@
+//go:build dobozcgo && cgo
+
+package dobozcgo
+
+import (
+	"bytes"
+	"fmt"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Runs every operation on the data with both this implementation and the reference implementation
+// The compressed blocks must be identical, and each decoder must decode both blocks to the original data
+// Returns nil if the implementations agree, or an error describing the first difference
+func CrossCheck(data []byte) error {
+	var c doboz.Compressor
+
+	if goSize, refSize := doboz.GetMaxCompressedSize(len(data)), GetMaxCompressedSize(len(data)); goSize != refSize {
+		return fmt.Errorf("dobozcgo: maximum compressed size of %d bytes is %d, reference is %d", len(data), goSize, refSize)
+	}
+
+	goCompressed := make([]byte, doboz.GetMaxCompressedSize(len(data)))
+	goResult, goCompressedSize := c.Compress(data, goCompressed)
+
+	refCompressed := make([]byte, GetMaxCompressedSize(len(data)))
+	refResult, refCompressedSize := Compress(data, refCompressed)
+
+	if goResult != refResult {
+		return fmt.Errorf("dobozcgo: compressing %d bytes returned %v, reference returned %v", len(data), goResult, refResult)
+	}
+
+	if goResult != doboz.RESULT_OK {
+		return nil
+	}
+
+	if !bytes.Equal(goCompressed[:goCompressedSize], refCompressed[:refCompressedSize]) {
+		return fmt.Errorf("dobozcgo: compressing %d bytes produced %d bytes, differing from the reference's %d bytes",
+			len(data), goCompressedSize, refCompressedSize)
+	}
+
+	if err := CrossCheckDecompress(goCompressed[:goCompressedSize], len(data)); err != nil {
+		return err
+	}
+
+	decompressed := make([]byte, len(data))
+	if result := Decompress(goCompressed[:goCompressedSize], decompressed); result != doboz.RESULT_OK {
+		return fmt.Errorf("dobozcgo: reference decoder failed on the compressed block: %v", result)
+	}
+
+	if !bytes.Equal(decompressed, data) {
+		return fmt.Errorf("dobozcgo: reference decoder produced different data")
+	}
+
+	return nil
+}
+
+// Decodes a possibly corrupted block with both implementations into a destination of the given size
+// Both decoders must return the same result, and on success the same data
+// Returns nil if the implementations agree, or an error describing the difference
+func CrossCheckDecompress(source []byte, destinationSize int) error {
+	var d doboz.Decompressor
+
+	goResult, goInfo := d.GetCompressionInfo(source)
+	refResult, refInfo := GetCompressionInfo(source)
+
+	if goResult != refResult || goInfo != refInfo {
+		return fmt.Errorf("dobozcgo: compression info is %v %+v, reference is %v %+v", goResult, goInfo, refResult, refInfo)
+	}
+
+	goDecompressed := make([]byte, destinationSize)
+	goResult = d.Decompress(source, goDecompressed)
+
+	refDecompressed := make([]byte, destinationSize)
+	refResult = Decompress(source, refDecompressed)
+
+	if goResult != refResult {
+		return fmt.Errorf("dobozcgo: decompressing returned %v, reference returned %v", goResult, refResult)
+	}
+
+	if goResult == doboz.RESULT_OK && !bytes.Equal(goDecompressed, refDecompressed) {
+		return fmt.Errorf("dobozcgo: decompressing produced different data than the reference")
+	}
+
+	return nil
+}
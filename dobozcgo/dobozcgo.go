@@ -0,0 +1,70 @@
+//go:build dobozcgo && cgo
+
+package dobozcgo
+
+// #cgo CXXFLAGS: -std=c++11
+// #cgo LDFLAGS: -ldoboz -lstdc++
+// #include "shim.h"
+import "C"
+
+import (
+	"unsafe"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Returns the maximum compressed size computed by the reference implementation
+func GetMaxCompressedSize(size int) int {
+	return int(C.dobozcgo_max_compressed_size(C.uint64_t(size)))
+}
+
+// Compresses a block of data with the reference implementation
+// On success, returns RESULT_OK and outputs the compressed size
+func Compress(source []byte, destination []byte) (doboz.Result, int) {
+	var compressedSize C.size_t
+
+	result := C.dobozcgo_compress(pointer(source), C.size_t(len(source)), pointer(destination), C.size_t(len(destination)), &compressedSize)
+	if doboz.Result(result) != doboz.RESULT_OK {
+		return doboz.Result(result), 0
+	}
+
+	return doboz.RESULT_OK, int(compressedSize)
+}
+
+// Decompresses a block of data with the reference implementation
+func Decompress(source []byte, destination []byte) doboz.Result {
+	return doboz.Result(C.dobozcgo_decompress(pointer(source), C.size_t(len(source)), pointer(destination), C.size_t(len(destination))))
+}
+
+// Retrieves information about a compressed block of data with the reference implementation
+func GetCompressionInfo(source []byte) (doboz.Result, doboz.CompressionInfo) {
+	var compressionInfo doboz.CompressionInfo
+	var uncompressedSize, compressedSize C.uint64_t
+	var version C.int
+
+	result := C.dobozcgo_compression_info(pointer(source), C.size_t(len(source)), &uncompressedSize, &compressedSize, &version)
+	if doboz.Result(result) != doboz.RESULT_OK {
+		return doboz.Result(result), compressionInfo
+	}
+
+	compressionInfo.UncompressedSize = uint64(uncompressedSize)
+	compressionInfo.CompressedSize = uint64(compressedSize)
+	compressionInfo.Version = int(version)
+
+	// The reference API does not report these, so they are read from the attribute byte
+	compressionInfo.IsStored = source[0]&128 != 0
+	compressionInfo.HeaderSize = 1 + 2*(int(source[0]>>3&7)+1)
+
+	return doboz.RESULT_OK, compressionInfo
+}
+
+// The C++ code rejects null pointers even for empty buffers, so point at a dummy byte instead
+var empty [1]byte
+
+func pointer(buffer []byte) unsafe.Pointer {
+	if len(buffer) == 0 {
+		return unsafe.Pointer(&empty[0])
+	}
+
+	return unsafe.Pointer(&buffer[0])
+}
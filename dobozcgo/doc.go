@@ -0,0 +1,8 @@
+// Package dobozcgo binds the original C++ Doboz library, so that this implementation can be cross-checked against it
+//
+// The bindings are only built with the dobozcgo build tag and cgo enabled
+// The Doboz headers and a static or shared build of the C++ library must be made available through the usual cgo
+// variables, for example:
+//
+//	CGO_CXXFLAGS="-I/path/to/doboz/Source" CGO_LDFLAGS="-L/path/to/doboz/lib" go test -tags dobozcgo ./...
+package dobozcgo
@@ -0,0 +1,67 @@
+//go:build dobozcgo && cgo
+
+package dobozcgo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	doboz "github.com/razzie/go-doboz"
+	"github.com/razzie/go-doboz/dobozcgo"
+	"github.com/razzie/go-doboz/dobozvectors"
+)
+
+// Records every vector with the reference implementation in the layout read by dobozvectors.CompareReference
+func recordReference(t *testing.T, dir string) {
+	t.Helper()
+
+	var c doboz.Compressor
+
+	for _, vector := range dobozvectors.All() {
+		raw := vector.Uncompressed
+
+		reference := make([]byte, dobozcgo.GetMaxCompressedSize(len(raw)))
+		result, referenceSize := dobozcgo.Compress(raw, reference)
+		if result != doboz.RESULT_OK {
+			t.Fatalf("%s: reference compression failed: %v", vector.Name, result)
+		}
+
+		compressed := make([]byte, doboz.GetMaxCompressedSize(len(raw)))
+		result, compressedSize := c.Compress(raw, compressed)
+		if result != doboz.RESULT_OK {
+			t.Fatalf("%s: compression failed: %v", vector.Name, result)
+		}
+
+		decoded := make([]byte, len(raw))
+		if result := dobozcgo.Decompress(compressed[:compressedSize], decoded); result != doboz.RESULT_OK {
+			t.Fatalf("%s: reference decompression failed: %v", vector.Name, result)
+		}
+
+		files := map[string][]byte{
+			vector.Name + ".raw":      raw,
+			vector.Name + ".doboz":    reference[:referenceSize],
+			vector.Name + ".go.doboz": compressed[:compressedSize],
+			vector.Name + ".go.raw":   decoded,
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestCompareReference(t *testing.T) {
+	dir := t.TempDir()
+	recordReference(t, dir)
+
+	mismatches, err := dobozvectors.CompareReference(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mismatch := range mismatches {
+		t.Error(mismatch)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build dobozcgo
+
+#include "shim.h"
+
+#include <Doboz/Compressor.h>
+#include <Doboz/Decompressor.h>
+
+extern "C" {
+
+uint64_t dobozcgo_max_compressed_size(uint64_t size)
+{
+	return doboz::Compressor::getMaxCompressedSize(size);
+}
+
+int dobozcgo_compress(const void* source, size_t source_size, void* destination, size_t destination_size, size_t* compressed_size)
+{
+	doboz::Compressor compressor;
+	return compressor.compress(source, source_size, destination, destination_size, *compressed_size);
+}
+
+int dobozcgo_decompress(const void* source, size_t source_size, void* destination, size_t destination_size)
+{
+	doboz::Decompressor decompressor;
+	return decompressor.decompress(source, source_size, destination, destination_size);
+}
+
+int dobozcgo_compression_info(const void* source, size_t source_size, uint64_t* uncompressed_size, uint64_t* compressed_size, int* version)
+{
+	doboz::Decompressor decompressor;
+	doboz::CompressionInfo info;
+
+	doboz::Result result = decompressor.getCompressionInfo(source, source_size, info);
+	if (result == doboz::RESULT_OK)
+	{
+		*uncompressed_size = info.uncompressedSize;
+		*compressed_size = info.compressedSize;
+		*version = info.version;
+	}
+
+	return result;
+}
+
+}
@@ -0,0 +1,22 @@
+//go:build dobozcgo
+
+#ifndef DOBOZCGO_SHIM_H
+#define DOBOZCGO_SHIM_H
+
+#include <stddef.h>
+#include <stdint.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+uint64_t dobozcgo_max_compressed_size(uint64_t size);
+int dobozcgo_compress(const void* source, size_t source_size, void* destination, size_t destination_size, size_t* compressed_size);
+int dobozcgo_decompress(const void* source, size_t source_size, void* destination, size_t destination_size);
+int dobozcgo_compression_info(const void* source, size_t source_size, uint64_t* uncompressed_size, uint64_t* compressed_size, int* version);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif
@@ -0,0 +1,169 @@
+// Package dobozconformance checks external implementations of the doboz format against the format rules
+//
+// The vectors are read from a directory in the layout written by the doboz-vectors command:
+// every vector is a pair of <name>.raw and <name>.doboz files, and the directory may contain other files,
+// such as a MANIFEST, which are ignored
+//
+// The implementation under test is run as separate processes:
+//   - the encoder reads uncompressed data from stdin and writes a single compressed block to stdout
+//   - the decoder reads a single compressed block from stdin and writes the uncompressed data to stdout
+//
+// Both must exit with a non-zero status on failure
+package dobozconformance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	doboz "github.com/razzie/go-doboz"
+	"github.com/razzie/go-doboz/dobozvectors"
+)
+
+// The time an external command may run for a single vector
+const COMMAND_TIMEOUT = 30 * time.Second
+
+// The implementation under test
+// Either command may be empty, in which case the checks needing it are skipped
+type Kit struct {
+	Encoder []string // the encoder command and its arguments
+	Decoder []string // the decoder command and its arguments
+
+	// Requires the encoder to produce exactly the compressed blocks of the vectors
+	// Without it, any block the Go decoder accepts and decodes to the original data conforms
+	Identical bool
+}
+
+// Runs the conformance checks on every vector in the directory
+//   - the decoder must decode every vector to its uncompressed data
+//   - the encoder's output of every vector must pass strict validation and decode to the uncompressed data,
+//     both with the Go decoder and with the decoder under test
+//   - the decoder must fail on every entry of the regression corpus that Decompress rejects
+//
+// Returns every mismatch found, or an error if the directory cannot be read
+func (k Kit) Run(dir string) ([]dobozvectors.Mismatch, error) {
+	rawFiles, err := filepath.Glob(filepath.Join(dir, "*.raw"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(rawFiles)
+
+	var mismatches []dobozvectors.Mismatch
+
+	for _, rawFile := range rawFiles {
+		name := strings.TrimSuffix(filepath.Base(rawFile), ".raw")
+
+		raw, err := os.ReadFile(rawFile)
+		if err != nil {
+			return mismatches, err
+		}
+
+		compressed, err := os.ReadFile(filepath.Join(dir, name+".doboz"))
+		if err != nil {
+			return mismatches, err
+		}
+
+		for _, reason := range k.check(raw, compressed) {
+			mismatches = append(mismatches, dobozvectors.Mismatch{Name: name, Reason: reason})
+		}
+	}
+
+	if len(k.Decoder) == 0 {
+		return mismatches, nil
+	}
+
+	for _, entry := range dobozvectors.Regressions() {
+		// The size limit is an option of this implementation, not a format rule
+		if entry.Expected == doboz.RESULT_OK || entry.Expected == doboz.RESULT_ERROR_SIZE_LIMIT_EXCEEDED {
+			continue
+		}
+
+		if _, err := run(k.Decoder, entry.Input); err == nil {
+			mismatches = append(mismatches, dobozvectors.Mismatch{
+				Name:   "regression " + entry.Name,
+				Reason: fmt.Sprintf("decoder accepted a block rejected with %v", entry.Expected),
+			})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// Checks a single vector and returns the reasons it does not conform
+func (k Kit) check(raw []byte, compressed []byte) (reasons []string) {
+	if len(k.Decoder) > 0 {
+		decoded, err := run(k.Decoder, compressed)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("decoder failed: %v", err))
+		} else if !bytes.Equal(decoded, raw) {
+			reasons = append(reasons, "decoder produced different data")
+		}
+	}
+
+	if len(k.Encoder) == 0 {
+		return reasons
+	}
+
+	encoded, err := run(k.Encoder, raw)
+	if err != nil {
+		return append(reasons, fmt.Sprintf("encoder failed: %v", err))
+	}
+
+	if k.Identical && !bytes.Equal(encoded, compressed) {
+		reasons = append(reasons, "encoder output differs from the vector")
+	}
+
+	// The encoder's output must be a valid block of the original data
+	d := doboz.NewDecompressor(doboz.WithStrictValidation())
+	decoded := make([]byte, len(raw))
+
+	if result := d.Decompress(encoded, decoded); result != doboz.RESULT_OK {
+		reasons = append(reasons, fmt.Sprintf("encoder output does not decode: %v", result))
+	} else if !bytes.Equal(decoded, raw) {
+		reasons = append(reasons, "encoder output decodes to different data")
+	}
+
+	if len(raw) > 0 && len(encoded) > doboz.GetMaxCompressedSize(len(raw)) {
+		reasons = append(reasons, fmt.Sprintf("encoder output of %d bytes exceeds the maximum compressed size", len(encoded)))
+	}
+
+	// The encoder's output must also round trip through the decoder under test
+	if len(k.Decoder) > 0 {
+		roundTripped, err := run(k.Decoder, encoded)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("decoder failed on the encoder output: %v", err))
+		} else if !bytes.Equal(roundTripped, raw) {
+			reasons = append(reasons, "decoder decodes the encoder output to different data")
+		}
+	}
+
+	return reasons
+}
+
+// Runs a command with the input on stdin and returns its stdout
+func run(command []string, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), COMMAND_TIMEOUT)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,5 @@
+// Package dobozgzip converts between gzip streams and doboz blocks without temporary files
+//
+// Converting to gzip streams the content through a fixed size window, but converting from gzip holds the whole content
+// in memory, because it is compressed as a single block, up to the size limit passed to TranscodeGzip if it has one
+package dobozgzip
@@ -0,0 +1,59 @@
+//go:build !dobozdecodeonly
+
+package dobozgzip
+
+import (
+	"compress/gzip"
+	"io"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Inflates a gzip stream and writes its content to dst as a doboz block, compressed with the specified options
+// Concatenated gzip members are joined, like gzip -d does
+// The content is compressed as a single block, so it is held in memory in full, there is no frame format to
+// compress it in bounded pieces
+// Inflating stops after maxSize bytes, so a small gzip stream cannot make the content exhaust the memory
+// A maxSize of 0 or less means no limit, which is only safe for trusted streams
+// An empty gzip stream writes nothing
+// Returns doboz.RESULT_ERROR_SIZE_LIMIT_EXCEEDED if the content is larger than maxSize bytes, the error of the gzip
+// decoder or of dst, or the Result if compression fails
+func TranscodeGzip(dst io.Writer, src io.Reader, maxSize int64, options ...doboz.CompressorOption) error {
+	r, err := gzip.NewReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var content []byte
+	if maxSize > 0 {
+		if content, err = io.ReadAll(io.LimitReader(r, maxSize)); err != nil {
+			return err
+		}
+
+		// Any content left after the limit makes the stream too large
+		if _, err := io.ReadFull(r, make([]byte, 1)); err == nil {
+			return doboz.RESULT_ERROR_SIZE_LIMIT_EXCEEDED
+		} else if err != io.EOF {
+			return err
+		}
+	} else if content, err = io.ReadAll(r); err != nil {
+		return err
+	}
+
+	// doboz does not compress empty data
+	if len(content) == 0 {
+		return nil
+	}
+
+	c := doboz.NewCompressor(options...)
+
+	compressed := make([]byte, c.MaxCompressedSize(len(content)))
+	result, compressedSize := c.Compress(content, compressed)
+	if result != doboz.RESULT_OK {
+		return result
+	}
+
+	_, err = dst.Write(compressed[:compressedSize])
+	return err
+}
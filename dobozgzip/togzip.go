@@ -0,0 +1,32 @@
+//go:build !dobozencodeonly
+
+package dobozgzip
+
+import (
+	"compress/gzip"
+	"io"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Decompresses a doboz block read from src and writes its content to dst as a gzip stream
+// The block is decompressed with a Decompressor with the specified options through DecompressTo, so only a fixed size
+// window of the content is held in memory besides the compressed block itself
+// An empty src writes an empty gzip stream
+// Returns the Result if the block cannot be decompressed, or the error of src or dst
+func TranscodeToGzip(dst io.Writer, src io.Reader, options ...doboz.DecompressorOption) error {
+	source, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+
+	w := gzip.NewWriter(dst)
+
+	if len(source) > 0 {
+		if err := doboz.NewDecompressor(options...).DecompressTo(w, source); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,72 @@
+//go:build !dobozdecodeonly
+
+package dobozhttp
+
+import (
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Compresses the request body into a doboz block, which is sent as the response body
+// The body is compressed as a single block, so it is read in full first; an empty body yields an empty response
+// The zero value is ready to use, and a CompressHandler is safe for concurrent use
+// The Compressors are pooled across the requests, so the fields must not change once the handler serves requests
+type CompressHandler struct {
+	MaxBodySize int64                    // the limit of the request body size, 0 means DEFAULT_MAX_BODY_SIZE
+	Checksum    bool                     // sends the checksum of the compressed data in CHECKSUM_HEADER
+	Options     []doboz.CompressorOption // the options of the pooled Compressors
+
+	compressors sync.Pool
+}
+
+func (h *CompressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r) {
+		return
+	}
+
+	source, err := io.ReadAll(http.MaxBytesReader(w, r.Body, bodyLimit(h.MaxBodySize)))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	var compressed []byte
+	if len(source) > 0 {
+		var result doboz.Result
+		if result, compressed = compressPooled(&h.compressors, h.Options, source); result != doboz.RESULT_OK {
+			fail(w, result)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(compressed)))
+	if h.Checksum {
+		w.Header().Set(CHECKSUM_HEADER, formatChecksum(crc32.Checksum(compressed, castagnoli)))
+	}
+
+	w.Write(compressed)
+}
+
+// Compresses a non-empty source with a Compressor of the pool, which creates one with the options if it is empty
+// A Compressor holds large match finder tables, so they are reused across the requests rather than created for each
+func compressPooled(pool *sync.Pool, options []doboz.CompressorOption, source []byte) (doboz.Result, []byte) {
+	c, ok := pool.Get().(*doboz.Compressor)
+	if !ok {
+		c = doboz.NewCompressor(options...)
+	}
+	defer pool.Put(c)
+
+	compressed := make([]byte, c.MaxCompressedSize(len(source)))
+	result, compressedSize := c.Compress(source, compressed)
+	if result != doboz.RESULT_OK {
+		return result, nil
+	}
+
+	return doboz.RESULT_OK, compressed[:compressedSize]
+}
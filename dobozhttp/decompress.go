@@ -0,0 +1,80 @@
+//go:build !dobozencodeonly
+
+package dobozhttp
+
+import (
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strconv"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Decompresses a doboz block sent as the request body, streaming the decompressed data as the response body
+// The block is checked in full before any output is sent, so decoding errors are always reported with a status code
+// An empty body yields an empty response, like CompressHandler produces for empty data
+// The zero value is ready to use, and a DecompressHandler is safe for concurrent use
+type DecompressHandler struct {
+	MaxBodySize    int64                      // the limit of the request body size, 0 means DEFAULT_MAX_BODY_SIZE
+	MaxDecodedSize uint64                     // the limit of the decompressed size, 0 means the limit of the body size
+	Checksum       bool                       // sends the checksum of the decompressed data in a CHECKSUM_HEADER trailer
+	Options        []doboz.DecompressorOption // the options of the Decompressor created for each request
+}
+
+func (h *DecompressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r) {
+		return
+	}
+
+	maxBodySize := bodyLimit(h.MaxBodySize)
+
+	source, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	maxDecodedSize := h.MaxDecodedSize
+	if maxDecodedSize == 0 {
+		maxDecodedSize = uint64(maxBodySize)
+	}
+
+	d := doboz.NewDecompressor(append([]doboz.DecompressorOption{doboz.WithMaxDecodedSize(maxDecodedSize)}, h.Options...)...)
+
+	var uncompressedSize uint64
+	if len(source) > 0 {
+		result, info := d.GetCompressionInfo(source)
+		if result == doboz.RESULT_OK {
+			result = d.Verify(source)
+		}
+
+		if result != doboz.RESULT_OK {
+			fail(w, result)
+			return
+		}
+
+		uncompressedSize = info.UncompressedSize
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+
+	// The checksum is only known once the data is sent, so it is sent as a trailer instead of the content length
+	if !h.Checksum {
+		w.Header().Set("Content-Length", strconv.FormatUint(uncompressedSize, 10))
+
+		if len(source) > 0 {
+			d.DecompressTo(w, source)
+		}
+		return
+	}
+
+	w.Header().Set("Trailer", CHECKSUM_HEADER)
+
+	checksum := crc32.New(castagnoli)
+	if len(source) > 0 {
+		d.DecompressTo(io.MultiWriter(w, checksum), source)
+	}
+
+	w.Header().Set(CHECKSUM_HEADER, formatChecksum(checksum.Sum32()))
+}
@@ -0,0 +1,62 @@
+// Package dobozhttp provides HTTP handlers compressing and decompressing request bodies with doboz, for running
+// doboz as a service for clients which cannot link Go code
+//
+// The handlers accept POST requests only, and answer with the processed body
+// Errors are reported with a plain text body: 413 if a size limit is exceeded, 400 if the body cannot be read or
+// decompressed, and 405 for other methods
+//
+// Recompressor re-encodes the responses of a reverse proxy as doboz for the clients accepting it
+// It buffers each response body in memory, up to DEFAULT_MAX_RECOMPRESS_SIZE (1 MB) unless MaxBodySize is set, and
+// passes larger bodies on unchanged; raise the limit only as far as the memory allows for all the responses in flight
+package dobozhttp
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// The limit of the request body size if none is configured
+const DEFAULT_MAX_BODY_SIZE = 64 << 20
+
+// The header carrying the CRC-32C (Castagnoli) checksum of the response body as 8 hexadecimal digits, if enabled
+const CHECKSUM_HEADER = "X-Doboz-Crc32c"
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Returns the limit of the request body size, DEFAULT_MAX_BODY_SIZE if it is 0
+func bodyLimit(maxBodySize int64) int64 {
+	if maxBodySize == 0 {
+		return DEFAULT_MAX_BODY_SIZE
+	}
+	return maxBodySize
+}
+
+// Rejects requests which are not POST requests
+func checkMethod(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "dobozhttp: only POST is allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+// Reports an error of reading the request body or of processing it
+func fail(w http.ResponseWriter, err error) {
+	var maxBytesError *http.MaxBytesError
+
+	status := http.StatusBadRequest
+	if errors.As(err, &maxBytesError) || errors.Is(err, doboz.RESULT_ERROR_SIZE_LIMIT_EXCEEDED) {
+		status = http.StatusRequestEntityTooLarge
+	}
+
+	http.Error(w, err.Error(), status)
+}
+
+func formatChecksum(checksum uint32) string {
+	return fmt.Sprintf("%08x", checksum)
+}
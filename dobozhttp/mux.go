@@ -0,0 +1,13 @@
+//go:build !dobozdecodeonly && !dobozencodeonly
+
+package dobozhttp
+
+import "net/http"
+
+// Creates a ServeMux serving the compression handler at /compress and the decompression handler at /decompress
+func NewServeMux(compress *CompressHandler, decompress *DecompressHandler) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/compress", compress)
+	mux.Handle("/decompress", decompress)
+	return mux
+}
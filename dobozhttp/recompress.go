@@ -0,0 +1,145 @@
+//go:build !dobozdecodeonly
+
+package dobozhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// The content coding of response bodies compressed by Recompressor
+const CONTENT_ENCODING = "doboz"
+
+// The limit of the body size buffered by Recompressor if none is configured
+// It is much lower than DEFAULT_MAX_BODY_SIZE, as every response in flight through the proxy may buffer that much
+const DEFAULT_MAX_RECOMPRESS_SIZE = 1 << 20
+
+// Re-encodes upstream responses as doboz for clients which accept the CONTENT_ENCODING content coding
+// Its ModifyResponse method is meant for httputil.ReverseProxy, and handles identity and gzip encoded responses
+// A doboz block has to be compressed as a whole, so the bodies are buffered; bodies larger than MaxBodySize, before
+// or after inflating gzip, are passed on unchanged, as are partial, empty and otherwise encoded responses
+// The zero value is ready to use, and a Recompressor is safe for concurrent use
+// Its Compressors are reused across the responses, so Options must be set before the first response
+type Recompressor struct {
+	MaxBodySize int64                    // the limit of the buffered body size, 0 means DEFAULT_MAX_RECOMPRESS_SIZE
+	Options     []doboz.CompressorOption // the options of the pooled Compressors
+
+	compressors sync.Pool
+}
+
+// Re-encodes the response body if the client accepts doboz, to be set as the ModifyResponse of a ReverseProxy
+// Returns the error of reading the upstream body
+func (rc *Recompressor) ModifyResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK || resp.Request == nil || resp.Request.Method == http.MethodHead {
+		return nil
+	}
+
+	resp.Header.Add("Vary", "Accept-Encoding")
+
+	if !acceptsDoboz(resp.Request.Header.Values("Accept-Encoding")) {
+		return nil
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding != "" && encoding != "identity" && encoding != "gzip" {
+		return nil
+	}
+
+	maxBodySize := rc.MaxBodySize
+	if maxBodySize == 0 {
+		maxBodySize = DEFAULT_MAX_RECOMPRESS_SIZE
+	}
+
+	// Keep the body as it is if it turns out to be too large
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return err
+	}
+
+	if int64(len(raw)) > maxBodySize {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(raw), resp.Body), resp.Body}
+		return nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+
+	source := raw
+	if encoding == "gzip" {
+		if source, err = inflate(raw, maxBodySize); err != nil {
+			// Pass malformed or too large gzip bodies on for the client to deal with
+			return nil
+		}
+	}
+
+	if len(source) == 0 {
+		return nil
+	}
+
+	result, compressed := compressPooled(&rc.compressors, rc.Options, source)
+	if result != doboz.RESULT_OK {
+		return nil
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(compressed))
+	resp.ContentLength = int64(len(compressed))
+	resp.Uncompressed = false
+	resp.Header.Set("Content-Encoding", CONTENT_ENCODING)
+	resp.Header.Set("Content-Length", strconv.Itoa(len(compressed)))
+	resp.Header.Del("Accept-Ranges")
+	resp.Header.Del("Etag")
+
+	return nil
+}
+
+// Reports whether the Accept-Encoding headers list the doboz content coding with a non-zero quality
+func acceptsDoboz(acceptEncodings []string) bool {
+	for _, header := range acceptEncodings {
+		for _, coding := range strings.Split(header, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			if !strings.EqualFold(strings.TrimSpace(name), CONTENT_ENCODING) {
+				continue
+			}
+
+			params = strings.ReplaceAll(params, " ", "")
+			quality, ok := strings.CutPrefix(params, "q=")
+			if !ok {
+				return true
+			}
+
+			q, err := strconv.ParseFloat(quality, 64)
+			return err == nil && q > 0
+		}
+	}
+
+	return false
+}
+
+// Inflates a gzip body, failing if it is larger than maxSize
+func inflate(raw []byte, maxSize int64) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	source, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(source)) > maxSize {
+		return nil, doboz.RESULT_ERROR_SIZE_LIMIT_EXCEEDED
+	}
+
+	return source, nil
+}
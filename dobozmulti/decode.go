@@ -0,0 +1,78 @@
+//go:build !dobozencodeonly
+
+package dobozmulti
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+var (
+	errEmpty        = errors.New("dobozmulti: missing codec tag")
+	errUnknownCodec = errors.New("dobozmulti: unknown codec tag")
+)
+
+// Decodes a buffer produced by Encode
+// The decoded size is limited to maxSize bytes for every codec, which protects against decompression bombs, 0 means
+// no limit
+// Returns doboz.RESULT_ERROR_SIZE_LIMIT_EXCEEDED if the data is larger, the Result if a doboz block cannot be
+// decompressed, or the error of the flate decoder
+func Decode(encoded []byte, maxSize uint64) ([]byte, error) {
+	if len(encoded) == 0 {
+		return nil, errEmpty
+	}
+
+	payload := encoded[1:]
+
+	switch encoded[0] {
+	case CODEC_STORED:
+		if maxSize != 0 && uint64(len(payload)) > maxSize {
+			return nil, doboz.RESULT_ERROR_SIZE_LIMIT_EXCEEDED
+		}
+
+		return append([]byte(nil), payload...), nil
+
+	case CODEC_DOBOZ:
+		d := doboz.NewDecompressor(doboz.WithMaxDecodedSize(maxSize))
+
+		result, info := d.GetCompressionInfo(payload)
+		if result != doboz.RESULT_OK {
+			return nil, result
+		}
+
+		decoded := make([]byte, info.UncompressedSize)
+		if result := d.Decompress(payload, decoded); result != doboz.RESULT_OK {
+			return nil, result
+		}
+
+		return decoded, nil
+
+	case CODEC_FLATE:
+		r := flate.NewReader(bytes.NewReader(payload))
+		defer r.Close()
+
+		// Read one byte more than allowed to tell whether the limit is exceeded
+		var limited io.Reader = r
+		if maxSize != 0 {
+			limited = io.LimitReader(r, int64(min(maxSize, 1<<62))+1)
+		}
+
+		decoded, err := io.ReadAll(limited)
+		if err != nil {
+			return nil, err
+		}
+
+		if maxSize != 0 && uint64(len(decoded)) > maxSize {
+			return nil, doboz.RESULT_ERROR_SIZE_LIMIT_EXCEEDED
+		}
+
+		return decoded, nil
+
+	default:
+		return nil, errUnknownCodec
+	}
+}
@@ -0,0 +1,13 @@
+// Package dobozmulti picks the smallest of several encodings for each buffer, for heterogeneous data where doboz
+// sometimes loses to other codecs
+//
+// An encoded buffer is a one-byte codec tag followed by the payload of that codec: the data itself for CODEC_STORED,
+// a doboz block for CODEC_DOBOZ, or a raw DEFLATE stream for CODEC_FLATE
+package dobozmulti
+
+// The codec tags
+const (
+	CODEC_STORED byte = 0
+	CODEC_DOBOZ  byte = 1
+	CODEC_FLATE  byte = 2
+)
@@ -0,0 +1,81 @@
+//go:build !dobozdecodeonly
+
+package dobozmulti
+
+import (
+	"bytes"
+	"compress/flate"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+type config struct {
+	compressor *doboz.Compressor
+	flate      bool
+	flateLevel int
+}
+
+// Configures Encode
+type Option func(*config)
+
+// Compresses with the specified compressor instead of a default one
+func WithCompressor(compressor *doboz.Compressor) Option {
+	return func(cfg *config) {
+		cfg.compressor = compressor
+	}
+}
+
+// Also tries compress/flate with the specified level, such as flate.BestCompression
+func WithFlate(level int) Option {
+	return func(cfg *config) {
+		cfg.flate = true
+		cfg.flateLevel = level
+	}
+}
+
+// Encodes the source with every enabled codec and returns the smallest result with its codec tag
+// The data is always tried stored and compressed with doboz, and with flate if WithFlate is given
+// On a tie, the codec which decodes faster wins: stored before doboz before flate
+// Returns an error only if the flate level is not valid
+func Encode(source []byte, options ...Option) ([]byte, error) {
+	var cfg config
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	if cfg.compressor == nil {
+		cfg.compressor = new(doboz.Compressor)
+	}
+
+	best := append([]byte{CODEC_STORED}, source...)
+
+	// doboz cannot compress empty data, which is stored in a single byte anyway
+	if len(source) > 0 {
+		compressed := make([]byte, 1+cfg.compressor.MaxCompressedSize(len(source)))
+		compressed[0] = CODEC_DOBOZ
+
+		result, compressedSize := cfg.compressor.Compress(source, compressed[1:])
+		if result == doboz.RESULT_OK && 1+compressedSize < len(best) {
+			best = compressed[:1+compressedSize]
+		}
+	}
+
+	if cfg.flate {
+		var buffer bytes.Buffer
+		buffer.WriteByte(CODEC_FLATE)
+
+		w, err := flate.NewWriter(&buffer, cfg.flateLevel)
+		if err != nil {
+			return nil, err
+		}
+
+		w.Write(source)
+		w.Close()
+
+		if buffer.Len() < len(best) {
+			best = buffer.Bytes()
+		}
+	}
+
+	return best, nil
+}
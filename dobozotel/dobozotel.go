@@ -0,0 +1,82 @@
+// Package dobozotel wraps doboz compression and decompression in OpenTelemetry spans
+// It lives in its own module, so the doboz package itself stays free of dependencies
+// Its go.mod replaces the doboz module with the parent directory, so it builds against the doboz package in this
+// repository; programs requiring dobozotel from outside the repository need the same replace directive
+package dobozotel
+
+import (
+	"context"
+
+	doboz "github.com/razzie/go-doboz"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/razzie/go-doboz"
+
+type config struct {
+	tracerProvider trace.TracerProvider
+}
+
+// Configures the spans created by Compress and Decompress
+type Option func(*config)
+
+// Creates spans with the specified provider instead of the global one
+func WithTracerProvider(tracerProvider trace.TracerProvider) Option {
+	return func(cfg *config) {
+		cfg.tracerProvider = tracerProvider
+	}
+}
+
+func newTracer(options []Option) trace.Tracer {
+	cfg := config{tracerProvider: otel.GetTracerProvider()}
+	for _, option := range options {
+		option(&cfg)
+	}
+	return cfg.tracerProvider.Tracer(instrumentationName)
+}
+
+// Compresses a block of data with the compressor inside a "doboz.Compress" span
+// The span records the uncompressed and compressed sizes and the compression ratio
+func Compress(ctx context.Context, c *doboz.Compressor, source []byte, destination []byte, options ...Option) (doboz.Result, int) {
+	_, span := newTracer(options).Start(ctx, "doboz.Compress",
+		trace.WithAttributes(attribute.Int("doboz.uncompressed_size", len(source))))
+	defer span.End()
+
+	result, compressedSize := c.Compress(source, destination)
+	if result != doboz.RESULT_OK {
+		span.SetStatus(codes.Error, result.Error())
+		return result, compressedSize
+	}
+
+	span.SetAttributes(
+		attribute.Int("doboz.compressed_size", compressedSize),
+		attribute.Float64("doboz.ratio", float64(len(source))/float64(compressedSize)))
+
+	return result, compressedSize
+}
+
+// Decompresses a block of data with the decompressor inside a "doboz.Decompress" span
+// The span records the compressed and uncompressed sizes and the compression ratio
+func Decompress(ctx context.Context, d *doboz.Decompressor, source []byte, destination []byte, options ...Option) doboz.Result {
+	_, span := newTracer(options).Start(ctx, "doboz.Decompress",
+		trace.WithAttributes(attribute.Int("doboz.source_size", len(source))))
+	defer span.End()
+
+	result := d.Decompress(source, destination)
+	if result != doboz.RESULT_OK {
+		span.SetStatus(codes.Error, result.Error())
+		return result
+	}
+
+	if infoResult, info := d.GetCompressionInfo(source); infoResult == doboz.RESULT_OK {
+		span.SetAttributes(
+			attribute.Int64("doboz.compressed_size", int64(info.CompressedSize)),
+			attribute.Int64("doboz.uncompressed_size", int64(info.UncompressedSize)),
+			attribute.Float64("doboz.ratio", float64(info.UncompressedSize)/float64(info.CompressedSize)))
+	}
+
+	return result
+}
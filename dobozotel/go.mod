@@ -0,0 +1,18 @@
+module github.com/razzie/go-doboz/dobozotel
+
+go 1.21
+
+require (
+	github.com/razzie/go-doboz v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.29.0
+	go.opentelemetry.io/otel/trace v1.29.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.29.0 // indirect
+)
+
+// dobozotel is developed together with the doboz package in the parent directory, until doboz publishes a tag
+replace github.com/razzie/go-doboz => ../
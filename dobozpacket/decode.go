@@ -0,0 +1,59 @@
+//go:build !dobozencodeonly
+
+package dobozpacket
+
+import (
+	"encoding/binary"
+	"errors"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+var (
+	errShortPacket  = errors.New("dobozpacket: packet shorter than the header")
+	errUnknownFlags = errors.New("dobozpacket: unknown flags in the packet header")
+)
+
+// Decodes a packet and returns its sequence number and payload
+// A compressed payload is decompressed with the decompressor into buf, which is grown if needed and may be reused
+// between packets, otherwise the payload is a slice of the packet
+// A nil decompressor decompresses with the default settings, but the decompressor should limit the decoded size with
+// doboz.WithMaxDecodedSize, as packets come from the network
+// Returns an error if the packet is malformed, or the Result if the payload cannot be decompressed
+func Parse(packet []byte, d *doboz.Decompressor, buf []byte) (uint32, []byte, error) {
+	if len(packet) < HEADER_SIZE {
+		return 0, nil, errShortPacket
+	}
+
+	flags := packet[0]
+	seq := binary.LittleEndian.Uint32(packet[1:])
+	payload := packet[HEADER_SIZE:]
+
+	if flags&^knownFlags != 0 {
+		return seq, nil, errUnknownFlags
+	}
+
+	if flags&FLAG_COMPRESSED == 0 {
+		return seq, payload, nil
+	}
+
+	if d == nil {
+		d = new(doboz.Decompressor)
+	}
+
+	result, info := d.GetCompressionInfo(payload)
+	if result != doboz.RESULT_OK {
+		return seq, nil, result
+	}
+
+	if uint64(cap(buf)) < info.UncompressedSize {
+		buf = make([]byte, info.UncompressedSize)
+	}
+	buf = buf[:info.UncompressedSize]
+
+	if result := d.Decompress(payload, buf); result != doboz.RESULT_OK {
+		return seq, nil, result
+	}
+
+	return seq, buf, nil
+}
@@ -0,0 +1,25 @@
+// Package dobozpacket frames payloads for datagram transports such as UDP, where packets can be lost or reordered
+//
+// Every packet is self-contained: its payload is compressed on its own, without any state shared with other packets,
+// so each packet can be decoded regardless of which other packets arrived, and in any order
+//
+// A packet is a HEADER_SIZE bytes header followed by the payload:
+//   - a flags byte, FLAG_COMPRESSED if the payload is a doboz block, or 0 if it is the data itself
+//   - the sequence number as a 32-bit little-endian integer, which the receiver can use to detect loss and reordering
+package dobozpacket
+
+// The size of the packet header
+const HEADER_SIZE = 5
+
+// The flags of the packet header
+const (
+	FLAG_COMPRESSED byte = 1 << 0
+
+	knownFlags = FLAG_COMPRESSED
+)
+
+// Reports whether sequence number a comes after b, allowing the sequence numbers to wrap around
+// Numbers less than half the range ahead of b come after it, as in serial number arithmetic (RFC 1982)
+func After(a, b uint32) bool {
+	return int32(a-b) > 0
+}
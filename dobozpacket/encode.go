@@ -0,0 +1,63 @@
+//go:build !dobozdecodeonly
+
+package dobozpacket
+
+import (
+	"encoding/binary"
+	"sync"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// The Compressors used when Append is given none, as creating one for each packet would allocate its tables each time
+var defaultCompressors = sync.Pool{
+	New: func() interface{} {
+		return new(doboz.Compressor)
+	},
+}
+
+// Appends a packet carrying the payload with the sequence number to dst, and returns the extended buffer
+// The payload is compressed with the compressor, and sent as it is if that does not make it smaller
+// A nil compressor compresses with the default settings, using a Compressor shared through a pool
+// Returns an error only if compression fails
+func Append(dst []byte, seq uint32, payload []byte, c *doboz.Compressor) ([]byte, error) {
+	start := len(dst)
+
+	dst = append(dst, 0, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(dst[start+1:], seq)
+
+	// doboz cannot compress empty payloads, which are not worth compressing anyway
+	if len(payload) > 0 {
+		if c == nil {
+			c = defaultCompressors.Get().(*doboz.Compressor)
+			defer defaultCompressors.Put(c)
+		}
+
+		maxCompressedSize := c.MaxCompressedSize(len(payload))
+		dst = grow(dst, maxCompressedSize)
+
+		block := dst[len(dst) : len(dst)+maxCompressedSize]
+		result, compressedSize := c.Compress(payload, block)
+		if result != doboz.RESULT_OK {
+			return dst[:start], result
+		}
+
+		if compressedSize < len(payload) {
+			dst[start] = FLAG_COMPRESSED
+			return dst[:len(dst)+compressedSize], nil
+		}
+	}
+
+	return append(dst, payload...), nil
+}
+
+// Makes room for n more bytes after the end of the buffer
+func grow(buffer []byte, n int) []byte {
+	if cap(buffer)-len(buffer) >= n {
+		return buffer
+	}
+
+	grown := make([]byte, len(buffer), len(buffer)+n)
+	copy(grown, buffer)
+	return grown
+}
@@ -0,0 +1,5 @@
+// Package dobozqueue decompresses blocks asynchronously on a fixed number of worker goroutines, in priority order
+//
+// It is meant for streaming game worlds and similar loaders, where the assets needed first must be decompressed
+// first: queued jobs can be re-prioritized as the needs change, or canceled when they are no longer needed
+package dobozqueue
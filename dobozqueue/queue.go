@@ -0,0 +1,239 @@
+//go:build !dobozencodeonly
+
+package dobozqueue
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Returned by the jobs canceled before they were started
+var ErrCanceled = errors.New("dobozqueue: job canceled")
+
+// Returned by the jobs submitted after the queue was closed
+var ErrClosed = errors.New("dobozqueue: queue closed")
+
+// Decompresses the submitted jobs on worker goroutines, the jobs with the highest priority first
+// Jobs with the same priority are started in the order they were submitted
+type Queue struct {
+	mu      sync.Mutex
+	cond    sync.Cond // signaled when a job is queued or the queue is closed
+	pending jobHeap
+	seq     uint64
+	closed  bool
+
+	workers sync.WaitGroup
+}
+
+// A decompression job, which can be waited on like a future
+type Job struct {
+	source      []byte
+	destination []byte
+	callback    func(*Job)
+
+	// Guarded by the mutex of the queue
+	queue    *Queue
+	priority int
+	seq      uint64
+	index    int // the index in the heap, -1 once the job was removed from it
+
+	done chan struct{}
+	err  error
+}
+
+// Creates a queue decompressing with the specified number of workers, each using a Decompressor with the options
+// The options are applied for every worker, so options sharing state, such as WithDecodeStats, must not be used
+func New(workers int, options ...doboz.DecompressorOption) *Queue {
+	q := &Queue{}
+	q.cond.L = &q.mu
+
+	for i := 0; i < max(workers, 1); i++ {
+		q.workers.Add(1)
+		go q.work(doboz.NewDecompressor(options...))
+	}
+
+	return q
+}
+
+// Queues decompressing a block of data into the destination, which must not be used until the job is done
+// The same rules apply to the source and the destination as for Decompress
+func (q *Queue) Submit(priority int, source []byte, destination []byte) *Job {
+	return q.SubmitFunc(priority, source, destination, nil)
+}
+
+// Queues decompressing a block of data like Submit, and calls the callback once the job is done
+// The callback is called on a worker goroutine, or on the calling goroutine if the job is canceled
+func (q *Queue) SubmitFunc(priority int, source []byte, destination []byte, callback func(*Job)) *Job {
+	job := &Job{
+		source:      source,
+		destination: destination,
+		callback:    callback,
+		queue:       q,
+		priority:    priority,
+		index:       -1,
+		done:        make(chan struct{}),
+	}
+
+	q.mu.Lock()
+
+	if q.closed {
+		q.mu.Unlock()
+		job.finish(ErrClosed)
+		return job
+	}
+
+	job.seq = q.seq
+	q.seq++
+	heap.Push(&q.pending, job)
+	q.cond.Signal()
+
+	q.mu.Unlock()
+	return job
+}
+
+// Returns the number of jobs waiting for a worker
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.pending.Len()
+}
+
+// Cancels the queued jobs and waits for the running ones to finish
+// The workers exit, and later submitted jobs fail with ErrClosed
+func (q *Queue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	canceled := q.pending
+	q.pending = nil
+	for _, job := range canceled {
+		job.index = -1
+	}
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	for _, job := range canceled {
+		job.finish(ErrCanceled)
+	}
+
+	q.workers.Wait()
+}
+
+func (q *Queue) work(d *doboz.Decompressor) {
+	defer q.workers.Done()
+
+	for {
+		q.mu.Lock()
+		for q.pending.Len() == 0 && !q.closed {
+			q.cond.Wait()
+		}
+
+		if q.closed {
+			q.mu.Unlock()
+			return
+		}
+
+		job := heap.Pop(&q.pending).(*Job)
+		q.mu.Unlock()
+
+		job.finish(d.Decompress(job.source, job.destination).Err())
+	}
+}
+
+// Changes the priority of the job if it is still queued
+// Returns false if the job was already started or canceled
+func (j *Job) SetPriority(priority int) bool {
+	q := j.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if j.index < 0 {
+		return false
+	}
+
+	j.priority = priority
+	heap.Fix(&q.pending, j.index)
+	return true
+}
+
+// Removes the job from the queue if it was not started yet, finishing it with ErrCanceled
+// Returns false if the job was already started or canceled
+func (j *Job) Cancel() bool {
+	q := j.queue
+	q.mu.Lock()
+
+	if j.index < 0 {
+		q.mu.Unlock()
+		return false
+	}
+
+	heap.Remove(&q.pending, j.index)
+	q.mu.Unlock()
+
+	j.finish(ErrCanceled)
+	return true
+}
+
+// Returns a channel which is closed once the job is done
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Waits for the job to finish
+// Returns nil on success, the Result if decompression fails, or ErrCanceled or ErrClosed
+func (j *Job) Wait() error {
+	<-j.done
+	return j.err
+}
+
+// Returns the destination the job decompresses into
+func (j *Job) Destination() []byte {
+	return j.destination
+}
+
+func (j *Job) finish(err error) {
+	j.err = err
+	close(j.done)
+
+	if j.callback != nil {
+		j.callback(j)
+	}
+}
+
+// A max-heap of jobs by priority, then a min-heap by submission order
+type jobHeap []*Job
+
+func (h jobHeap) Len() int {
+	return len(h)
+}
+
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *jobHeap) Push(x interface{}) {
+	job := x.(*Job)
+	job.index = len(*h)
+	*h = append(*h, job)
+}
+
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	job := old[len(old)-1]
+	old[len(old)-1] = nil
+	job.index = -1
+	*h = old[:len(old)-1]
+	return job
+}
@@ -0,0 +1,95 @@
+// Package doboztest provides property checks for code that wraps doboz, to be used from test suites
+package doboztest
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Checks the properties every input must satisfy:
+//   - Compress succeeds and stays within GetMaxCompressedSize
+//   - CompressedSize predicts the compressed size exactly
+//   - the header parses to the same information every time, and is recognized by Sniff
+//   - the block passes strict verification and decompresses to the original data
+func CheckRoundTrip(tb testing.TB, data []byte) {
+	tb.Helper()
+
+	if len(data) == 0 {
+		return
+	}
+
+	var c doboz.Compressor
+	d := doboz.NewDecompressor(doboz.WithStrictValidation())
+
+	// Compress within the bound
+	maxCompressedSize := doboz.GetMaxCompressedSize(len(data))
+	compressed := make([]byte, maxCompressedSize)
+
+	result, compressedSize := c.Compress(data, compressed)
+	if result != doboz.RESULT_OK {
+		tb.Fatalf("doboztest: compressing %d bytes failed: %v", len(data), result)
+	}
+	if compressedSize > maxCompressedSize {
+		tb.Fatalf("doboztest: compressed size %d exceeds the bound %d", compressedSize, maxCompressedSize)
+	}
+	compressed = compressed[:compressedSize]
+
+	if result, size := c.CompressedSize(data); result != doboz.RESULT_OK || size != compressedSize {
+		tb.Fatalf("doboztest: CompressedSize returned %d (%v), Compress produced %d bytes", size, result, compressedSize)
+	}
+
+	// Parse the header repeatedly
+	result, info := d.GetCompressionInfo(compressed)
+	if result != doboz.RESULT_OK {
+		tb.Fatalf("doboztest: parsing the header failed: %v", result)
+	}
+	if info.UncompressedSize != uint64(len(data)) || info.CompressedSize != uint64(compressedSize) {
+		tb.Fatalf("doboztest: header declares %d/%d bytes, expected %d/%d",
+			info.UncompressedSize, info.CompressedSize, len(data), compressedSize)
+	}
+	if _, again := d.GetCompressionInfo(compressed); again != info {
+		tb.Fatalf("doboztest: parsing the header again returned %+v instead of %+v", again, info)
+	}
+	if format, _ := doboz.Sniff(compressed); format != doboz.FORMAT_BLOCK {
+		tb.Fatalf("doboztest: Sniff does not recognize the block")
+	}
+
+	// Decompress to the original data
+	if result := d.Verify(compressed); result != doboz.RESULT_OK {
+		tb.Fatalf("doboztest: verifying the block failed: %v", result)
+	}
+
+	decompressed := make([]byte, len(data))
+	if result := d.Decompress(compressed, decompressed); result != doboz.RESULT_OK {
+		tb.Fatalf("doboztest: decompressing the block failed: %v", result)
+	}
+	if !bytes.Equal(decompressed, data) {
+		tb.Fatalf("doboztest: the block decompresses to different data")
+	}
+}
+
+// Runs CheckRoundTrip on count inputs created by the generator
+// Every input gets its own deterministically seeded random source, so failures can be reproduced
+func CheckGenerator(tb testing.TB, count int, generate func(r *rand.Rand) []byte) {
+	tb.Helper()
+
+	for seed := int64(0); seed < int64(count); seed++ {
+		checkSeed(tb, seed, generate)
+	}
+}
+
+func checkSeed(tb testing.TB, seed int64, generate func(r *rand.Rand) []byte) {
+	tb.Helper()
+
+	// Fatal errors stop the test, but deferred calls still run
+	defer func() {
+		if tb.Failed() {
+			tb.Logf("doboztest: the failing input was generated with seed %d", seed)
+		}
+	}()
+
+	CheckRoundTrip(tb, generate(rand.New(rand.NewSource(seed))))
+}
@@ -0,0 +1,67 @@
+package dobozvectors
+
+import (
+	"fmt"
+	"sort"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// Collects the encoding cases of a block
+type coverage map[string]bool
+
+func (c coverage) OnBlock(header doboz.Header) {
+	if header.IsStored {
+		c["stored"] = true
+	}
+}
+
+func (c coverage) OnControlWord(position int, controlWord uint) {}
+
+func (c coverage) OnLiteral(position int, literal byte) {}
+
+func (c coverage) OnMatch(position int, match doboz.Match) {
+	lengthCode := match.Length - doboz.MIN_MATCH_LENGTH
+	switch {
+	case lengthCode == 0 && match.Offset < 64:
+		c["match-00"] = true
+	case lengthCode == 0 && match.Offset < 16384:
+		c["match-01"] = true
+	case lengthCode < 16 && match.Offset < 1024:
+		c["match-10"] = true
+	case lengthCode < 32 && match.Offset < 65536:
+		c["match-011"] = true
+	default:
+		c["match-111"] = true
+	}
+}
+
+// Returns the encoding cases a vector covers, named like the vectors covering them:
+// "header-1", "header-2", "header-4" or "header-8" for the header width, "stored" for stored blocks,
+// and "match-00", "match-01", "match-10", "match-011" and "match-111" for the match codes used
+// Fails if the vector does not decode to its uncompressed data under strict validation
+func Cases(vector Vector) ([]string, error) {
+	covered := make(coverage)
+
+	d := doboz.NewDecompressor(doboz.WithDecodeTracer(covered), doboz.WithStrictValidation())
+	decompressed := make([]byte, len(vector.Uncompressed))
+
+	if result := d.Decompress(vector.Compressed, decompressed); result != doboz.RESULT_OK {
+		return nil, fmt.Errorf("%s: %w", vector.Name, result)
+	}
+
+	if string(decompressed) != string(vector.Uncompressed) {
+		return nil, fmt.Errorf("%s: decompresses to different data", vector.Name)
+	}
+
+	headerWidth := (vector.Compressed[0]>>3)&7 + 1
+	covered[fmt.Sprintf("header-%d", headerWidth)] = true
+
+	cases := make([]string, 0, len(covered))
+	for name := range covered {
+		cases = append(cases, name)
+	}
+	sort.Strings(cases)
+
+	return cases, nil
+}
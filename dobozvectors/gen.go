@@ -0,0 +1,51 @@
+//go:build ignore
+
+// Regenerates the embedded vectors and checks that they cover every encoding case
+// Run it with go generate
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/razzie/go-doboz/dobozvectors"
+)
+
+func main() {
+	vectors := dobozvectors.Generate()
+
+	// Every vector named after an encoding case must cover it
+	for _, vector := range vectors {
+		cases, err := dobozvectors.Cases(vector)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if strings.HasPrefix(vector.Name, "size-") {
+			continue
+		}
+
+		if !slices.Contains(cases, vector.Name) {
+			log.Fatalf("%s does not cover its encoding case", vector.Name)
+		}
+	}
+
+	if err := os.RemoveAll("data"); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Mkdir("data", 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, vector := range vectors {
+		if err := os.WriteFile(filepath.Join("data", vector.Name+".raw"), vector.Uncompressed, 0644); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join("data", vector.Name+".doboz"), vector.Compressed, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package dobozvectors
+
+import (
+	"math/rand"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// An input of the vector set together with what it is meant to cover
+type input struct {
+	name string
+	data []byte
+}
+
+// The compressor options of the inputs which are not compressed with the defaults
+var inputOptions = map[string][]doboz.CompressorOption{
+	"header-8": {doboz.WithWideHeader()},
+}
+
+// Returns the uncompressed inputs of the vector set
+// The inputs are built from a fixed seed, so they never change
+func inputs() []input {
+	r := rand.New(rand.NewSource(1))
+	random := func(n int) []byte {
+		data := make([]byte, n)
+		r.Read(data)
+		return data
+	}
+	join := func(parts ...[]byte) []byte {
+		var data []byte
+		for _, part := range parts {
+			data = append(data, part...)
+		}
+		return data
+	}
+
+	key := random(300)
+	zeros := make([]byte, 100000)
+	tail := []byte("0123456789abcdef")
+
+	return []input{
+		// Sizes around the tail length and the smallest blocks
+		{"size-1", []byte("a")},
+		{"size-8", []byte("abcdefgh")},
+		{"size-9", []byte("abcdefghi")},
+		{"size-12", []byte("aaaaaaaaaaaa")},
+
+		// Every match code
+		{"match-00", join(zeros[:300], key[:20], key[:3], zeros[:300])},
+		{"match-01", join(zeros[:300], key[:3], random(200), key[:3], zeros[:300])},
+		{"match-10", join(zeros[:300], key[:10], random(100), key[:10], zeros[:300])},
+		{"match-011", join(key[:30], zeros[:2000], key[:30], tail)},
+		{"match-111", join(key, zeros, key, tail)},
+
+		// Every header width the compressor produces and a stored block
+		{"header-1", join(key[:50], key[:50], key[:50])},
+		{"header-2", join(zeros[:1000], key[:100], zeros[:1000])},
+		{"header-4", join(zeros, key[:100], zeros)},
+		{"header-8", join(key[:100], zeros[:1000], key[:100])},
+		{"stored", random(1000)},
+	}
+}
+
+// Builds the vector set by compressing its inputs with the current compressor
+// The embedded vectors are the output of this function at the time they were generated
+func Generate() []Vector {
+	var vectors []Vector
+
+	for _, in := range inputs() {
+		c := doboz.NewCompressor(inputOptions[in.name]...)
+		compressed := make([]byte, c.MaxCompressedSize(len(in.data)))
+		result, compressedSize := c.Compress(in.data, compressed)
+		if result != doboz.RESULT_OK {
+			panic(result)
+		}
+
+		vectors = append(vectors, Vector{
+			Name:         in.name,
+			Uncompressed: in.data,
+			Compressed:   compressed[:compressedSize],
+		})
+	}
+
+	return vectors
+}
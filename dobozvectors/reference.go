@@ -0,0 +1,107 @@
+package dobozvectors
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+// A difference between this implementation and the reference C++ implementation
+type Mismatch struct {
+	Name   string
+	Reason string
+}
+
+func (m Mismatch) String() string {
+	return m.Name + ": " + m.Reason
+}
+
+// Compares this implementation against files recorded with the reference C++ implementation
+// For every <name>.raw file in the directory:
+//   - <name>.doboz is the reference encoder's output, which must decode to the raw data and must be
+//     identical to what the Go compressor produces
+//   - <name>.go.doboz, if present, is the Go compressor's output that was handed to the reference decoder,
+//     and <name>.go.raw is what the reference decoder produced from it, which must equal the raw data
+//
+// Returns every mismatch found, or an error if the directory cannot be read
+func CompareReference(dir string) ([]Mismatch, error) {
+	rawFiles, err := filepath.Glob(filepath.Join(dir, "*.raw"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(rawFiles)
+
+	var c doboz.Compressor
+	var d doboz.Decompressor
+	var mismatches []Mismatch
+
+	for _, rawFile := range rawFiles {
+		name := strings.TrimSuffix(filepath.Base(rawFile), ".raw")
+		if strings.HasSuffix(name, ".go") {
+			continue
+		}
+
+		mismatch := func(format string, args ...interface{}) {
+			mismatches = append(mismatches, Mismatch{Name: name, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		raw, err := os.ReadFile(rawFile)
+		if err != nil {
+			return mismatches, err
+		}
+
+		// The Go compressor's output
+		compressed := make([]byte, doboz.GetMaxCompressedSize(len(raw)))
+		result, compressedSize := c.Compress(raw, compressed)
+		if result != doboz.RESULT_OK {
+			mismatch("compression failed: %v", result)
+			continue
+		}
+		compressed = compressed[:compressedSize]
+
+		// Decode the reference encoder's output
+		reference, err := os.ReadFile(filepath.Join(dir, name+".doboz"))
+		if err != nil {
+			return mismatches, err
+		}
+
+		decoded := make([]byte, len(raw))
+		if result := d.Decompress(reference, decoded); result != doboz.RESULT_OK {
+			mismatch("decoding the reference output failed: %v", result)
+		} else if !bytes.Equal(decoded, raw) {
+			mismatch("the reference output decodes to different data")
+		}
+
+		if !bytes.Equal(compressed, reference) {
+			mismatch("the Go compressor's output differs from the reference output")
+		}
+
+		// Check the reference decoding of the Go compressor's output, if it was recorded
+		goCompressed, err := os.ReadFile(filepath.Join(dir, name+".go.doboz"))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return mismatches, err
+		}
+
+		goDecoded, err := os.ReadFile(filepath.Join(dir, name+".go.raw"))
+		if err != nil {
+			return mismatches, err
+		}
+
+		if !bytes.Equal(goCompressed, compressed) {
+			mismatch("the recorded Go compressor output is outdated")
+		}
+
+		if !bytes.Equal(goDecoded, raw) {
+			mismatch("the reference decoder decodes the Go compressor's output to different data")
+		}
+	}
+
+	return mismatches, nil
+}
@@ -0,0 +1,121 @@
+package dobozvectors
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+//go:embed regressions
+var regressions embed.FS
+
+// Blocks declaring more data than this are rejected during replay instead of being allocated
+const REPLAY_MAX_DECODED_SIZE = 16 << 20
+
+// A previously found input which crashed or was mis-decoded, together with the result Decompress must return for it
+// New entries are added as regressions/<name>.doboz files and a "<name> <result>" line in regressions/MANIFEST
+type Regression struct {
+	Name     string
+	Input    []byte
+	Expected doboz.Result
+}
+
+var resultNames = map[string]doboz.Result{
+	"RESULT_OK":                         doboz.RESULT_OK,
+	"RESULT_ERROR_BUFFER_TOO_SMALL":     doboz.RESULT_ERROR_BUFFER_TOO_SMALL,
+	"RESULT_ERROR_CORRUPTED_DATA":       doboz.RESULT_ERROR_CORRUPTED_DATA,
+	"RESULT_ERROR_UNSUPPORTED_VERSION":  doboz.RESULT_ERROR_UNSUPPORTED_VERSION,
+	"RESULT_ERROR_SIZE_LIMIT_EXCEEDED":  doboz.RESULT_ERROR_SIZE_LIMIT_EXCEEDED,
+	"RESULT_ERROR_TRAILING_DATA":        doboz.RESULT_ERROR_TRAILING_DATA,
+	"RESULT_ERROR_OUTPUT_SIZE_MISMATCH": doboz.RESULT_ERROR_OUTPUT_SIZE_MISMATCH,
+	"RESULT_ERROR_STREAM_SIZE_MISMATCH": doboz.RESULT_ERROR_STREAM_SIZE_MISMATCH,
+	"RESULT_ERROR_TRUNCATED":            doboz.RESULT_ERROR_TRUNCATED,
+}
+
+// Returns the regression corpus in manifest order
+func Regressions() []Regression {
+	manifest, err := regressions.ReadFile("regressions/MANIFEST")
+	if err != nil {
+		panic(err)
+	}
+
+	var entries []Regression
+
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) != 2 {
+			panic("dobozvectors: invalid regression manifest line: " + scanner.Text())
+		}
+
+		expected, ok := resultNames[fields[1]]
+		if !ok {
+			panic("dobozvectors: unknown result in regression manifest line: " + scanner.Text())
+		}
+
+		input, err := regressions.ReadFile(path.Join("regressions", fields[0]+".doboz"))
+		if err != nil {
+			panic(err)
+		}
+
+		entries = append(entries, Regression{Name: fields[0], Input: input, Expected: expected})
+	}
+
+	return entries
+}
+
+// Replays every input of the regression corpus through Decompress and the other decoding functions
+// Returns a mismatch for every entry where Decompress returns an unexpected result, or where any of the functions panics
+func ReplayCorpus() []Mismatch {
+	var mismatches []Mismatch
+
+	for _, entry := range Regressions() {
+		mismatches = append(mismatches, replay(entry)...)
+	}
+
+	return mismatches
+}
+
+func replay(entry Regression) (mismatches []Mismatch) {
+	mismatch := func(format string, args ...interface{}) {
+		mismatches = append(mismatches, Mismatch{Name: entry.Name, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			mismatch("panic: %v", r)
+		}
+	}()
+
+	d := doboz.NewDecompressor(doboz.WithMaxDecodedSize(REPLAY_MAX_DECODED_SIZE))
+
+	// Size the destination by the header, if it can be trusted
+	destinationSize := 0
+	if result, info := d.GetCompressionInfo(entry.Input); result == doboz.RESULT_OK {
+		destinationSize = int(info.UncompressedSize)
+	}
+	destination := make([]byte, destinationSize)
+
+	if result := d.Decompress(entry.Input, destination); result != entry.Expected {
+		mismatch("Decompress returned %v instead of %v", result, entry.Expected)
+	}
+
+	// The other decoding paths must not crash either
+	d.Verify(entry.Input)
+	d.DecompressPrefix(entry.Input, destinationSize)
+	d.Salvage(entry.Input, destination)
+	d.DecompressTo(io.Discard, entry.Input)
+	d.DumpTokens(entry.Input, io.Discard)
+
+	return mismatches
+}
@@ -0,0 +1,58 @@
+// Package dobozvectors contains canonical pairs of uncompressed and compressed doboz blocks
+// The vectors cover every match code, every header width the compressor produces, stored blocks and
+// sizes around the tail length, so ports and forks can check their encoders and decoders against them
+package dobozvectors
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:generate go run gen.go
+
+//go:embed data
+var data embed.FS
+
+// A canonical pair of uncompressed data and the block the reference Go compressor produces from it
+type Vector struct {
+	Name         string
+	Uncompressed []byte
+	Compressed   []byte
+}
+
+// Returns all the vectors ordered by name
+func All() []Vector {
+	entries, err := data.ReadDir("data")
+	if err != nil {
+		panic(err)
+	}
+
+	var vectors []Vector
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".doboz") {
+			continue
+		}
+
+		name = strings.TrimSuffix(name, ".doboz")
+		vectors = append(vectors, Vector{
+			Name:         name,
+			Uncompressed: readFile(name + ".raw"),
+			Compressed:   readFile(name + ".doboz"),
+		})
+	}
+
+	sort.Slice(vectors, func(i, j int) bool { return vectors[i].Name < vectors[j].Name })
+	return vectors
+}
+
+func readFile(name string) []byte {
+	content, err := data.ReadFile(path.Join("data", name))
+	if err != nil {
+		panic(err)
+	}
+	return content
+}
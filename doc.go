@@ -0,0 +1,16 @@
+// Package doboz is a port of the Doboz compression library, which compresses slowly but decompresses very fast
+//
+// Programs which only ever decompress can be built with the dobozdecodeonly build tag
+// It leaves out the Compressor and everything built on it, as well as the logging and profiling support of the
+// Decompressor, which considerably reduces the size of the binary
+//
+// Conversely, tools which only compress, such as asset pipelines, can be built with the dobozencodeonly build tag
+// It leaves out the Decompressor and everything built on it, including DecompressTo
+//
+// Under TinyGo, and in builds with the dobozsmallwindow build tag, the compressor only looks MATCH_WINDOW_SIZE bytes
+// back for matches, so it needs a few hundred kilobytes of memory instead of tens of megabytes
+// TinyGo builds also leave out the logging and profiling support
+//
+// Builds with the noasm build tag only use portable Go code, without assembly or unsafe conversions, which can be
+// confirmed at runtime with PureGo; Implementation names the implementation selected by the build
+package doboz
@@ -0,0 +1,121 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+type dumpedHeader struct {
+	Version          int    `json:"version"`
+	IsStored         bool   `json:"stored"`
+	HeaderSize       int    `json:"headerSize"`
+	UncompressedSize uint64 `json:"uncompressedSize"`
+	CompressedSize   uint64 `json:"compressedSize"`
+}
+
+type dumpedToken struct {
+	Type           string `json:"type"` // "literals" or "match"
+	InputPosition  int    `json:"inputPosition"`
+	OutputPosition int    `json:"outputPosition"`
+	Length         int    `json:"length"`
+	Offset         int    `json:"offset,omitempty"`
+}
+
+// Writes the header and the tokens of a compressed block as JSON
+// Consecutive literals are merged into runs, matches are listed with their offset and length
+// The output is an object with a "header" and a "tokens" field, and it is written as the block is decoded,
+// so it ends prematurely if the block is corrupted
+// Returns nil on success, the Result on decoding errors, or the error of the writer
+func (d *Decompressor) DumpTokens(source []byte, w io.Writer) error {
+	// Decode the header
+	decodeHeaderResult, header, headerSize := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK {
+		return decodeHeaderResult
+	}
+
+	// Check whether the supplied buffer is large enough
+	if uint64(len(source)) < header.CompressedSize {
+		return RESULT_ERROR_BUFFER_TOO_SMALL
+	}
+
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+
+	bw.WriteString(`{"header":`)
+	encoder.Encode(dumpedHeader{
+		Version:          header.Version,
+		IsStored:         header.IsStored,
+		HeaderSize:       headerSize,
+		UncompressedSize: header.UncompressedSize,
+		CompressedSize:   header.CompressedSize,
+	})
+	bw.WriteString(`,"tokens":[`)
+
+	tokens := d.newTokenReader(source, header, headerSize)
+	tokenCount := 0
+
+	var literals *dumpedToken
+	writeToken := func(tok *dumpedToken) {
+		if tokenCount > 0 {
+			bw.WriteByte(',')
+		}
+		encoder.Encode(tok)
+		tokenCount++
+	}
+
+	for {
+		result, tok, ok := tokens.next()
+		if result != RESULT_OK {
+			bw.Flush()
+			return result
+		}
+
+		if !ok {
+			break
+		}
+
+		if !tok.isMatch {
+			// Extend the current literal run unless a control word separates the literals
+			if literals != nil && literals.InputPosition+literals.Length == tok.inputPosition {
+				literals.Length += tok.length
+				continue
+			}
+
+			if literals != nil {
+				writeToken(literals)
+			}
+
+			literals = &dumpedToken{
+				Type:           "literals",
+				InputPosition:  tok.inputPosition,
+				OutputPosition: tok.outputPosition,
+				Length:         tok.length,
+			}
+			continue
+		}
+
+		if literals != nil {
+			writeToken(literals)
+			literals = nil
+		}
+
+		writeToken(&dumpedToken{
+			Type:           "match",
+			InputPosition:  tok.inputPosition,
+			OutputPosition: tok.outputPosition,
+			Length:         tok.length,
+			Offset:         tok.offset,
+		})
+	}
+
+	if literals != nil {
+		writeToken(literals)
+	}
+
+	bw.WriteString("]}\n")
+	return bw.Flush()
+}
@@ -0,0 +1,40 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+import "math"
+
+const (
+	ENTROPY_CHECK_MIN_SIZE  = 4096 // smaller inputs cannot be judged reliably by their byte distribution
+	ENTROPY_CHECK_THRESHOLD = 7.95 // bits per byte above which data is considered incompressible
+)
+
+// Tells whether the data looks already compressed or encrypted, based on the entropy of its byte distribution
+// This is only a heuristic: random data repeated at long distances still compresses, but is not detected
+func looksIncompressible(source []byte) bool {
+	if len(source) < ENTROPY_CHECK_MIN_SIZE {
+		return false
+	}
+
+	return byteEntropy(source) > ENTROPY_CHECK_THRESHOLD
+}
+
+// Returns the Shannon entropy of the byte distribution of the data in bits per byte
+func byteEntropy(data []byte) float64 {
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+
+	entropy := 0.0
+	size := float64(len(data))
+
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / size
+			entropy -= p * math.Log2(p)
+		}
+	}
+
+	return entropy
+}
@@ -0,0 +1,44 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+const (
+	ESTIMATE_SAMPLE_COUNT = 8
+	ESTIMATE_SAMPLE_SIZE  = 64 * 1024
+)
+
+// Estimates the compression ratio (uncompressed size / compressed size) of a block of data
+// It compresses ESTIMATE_SAMPLE_COUNT evenly spaced samples of ESTIMATE_SAMPLE_SIZE bytes without writing any output
+// and extrapolates from them, so its cost does not depend on the size of the data
+// Matches between samples are not found, so data with a lot of long distance redundancy is underestimated
+// Returns 0 for empty data
+func (c *Compressor) EstimateRatio(source []byte) float64 {
+	if len(source) == 0 {
+		return 0
+	}
+
+	defer c.suspendHooks()()
+
+	// Small inputs are compressed entirely
+	if len(source) <= ESTIMATE_SAMPLE_COUNT*ESTIMATE_SAMPLE_SIZE {
+		_, compressedSize := c.encode(source, nil)
+		return float64(len(source)) / float64(compressedSize)
+	}
+
+	sampledSize := 0
+	compressedSize := 0
+
+	// Spread the samples evenly, with the first one at the beginning and the last one at the end of the data
+	sampleDistance := (len(source) - ESTIMATE_SAMPLE_SIZE) / (ESTIMATE_SAMPLE_COUNT - 1)
+
+	for i := 0; i < ESTIMATE_SAMPLE_COUNT; i++ {
+		sampleStart := i * sampleDistance
+		sample := source[sampleStart : sampleStart+ESTIMATE_SAMPLE_SIZE]
+
+		_, sampleCompressedSize := c.encode(sample, nil)
+		sampledSize += len(sample)
+		compressedSize += sampleCompressedSize
+	}
+
+	return float64(sampledSize) / float64(compressedSize)
+}
@@ -0,0 +1,23 @@
+package doboz
+
+import "encoding/binary"
+
+// The word accesses used by FastRead and FastWrite
+// They go through encoding/binary, which the compiler turns into single unaligned loads and stores on the platforms
+// allowing them, so accessing the memory directly with unsafe is no faster
+
+func load16(source []byte) uint16 {
+	return binary.LittleEndian.Uint16(source)
+}
+
+func load32(source []byte) uint32 {
+	return binary.LittleEndian.Uint32(source)
+}
+
+func store16(destination []byte, word uint16) {
+	binary.LittleEndian.PutUint16(destination, word)
+}
+
+func store32(destination []byte, word uint32) {
+	binary.LittleEndian.PutUint32(destination, word)
+}
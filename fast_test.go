@@ -0,0 +1,39 @@
+//go:build !dobozdecodeonly && !dobozencodeonly
+
+package doboz
+
+import "testing"
+
+// Checks that the word accesses follow the little-endian layout of the format whatever the byte order of the
+// platform, and at every alignment, so running the tests on a big-endian or strict-alignment platform such as s390x
+// covers them
+func TestFastReadWrite(t *testing.T) {
+	buffer := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b}
+
+	for offset := 0; offset < 4; offset++ {
+		source := buffer[offset:]
+
+		for size := 1; size <= 4; size++ {
+			var want uint
+			for i := size - 1; i >= 0; i-- {
+				want = want<<8 | uint(source[i])
+			}
+
+			// Reading 3 bytes loads a whole word, the callers only use the low 3 bytes
+			mask := uint(1)<<(8*size) - 1
+			if got := FastRead(source, size) & mask; got != want {
+				t.Errorf("FastRead at offset %d of %d bytes = %#x, want %#x", offset, size, got, want)
+			}
+
+			destination := make([]byte, offset+WORD_SIZE)
+			FastWrite(destination[offset:], want, size)
+			for i := 0; i < size; i++ {
+				if destination[offset+i] != source[i] {
+					t.Errorf("FastWrite at offset %d of %d bytes wrote %x, want %x", offset, size,
+						destination[offset:offset+size], source[:size])
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package doboz
+
+// Faults injected into Compress or Decompress, so error handling can be exercised deterministically
+// Meant for tests of this package and of its callers, never for production use
+type Faults struct {
+	// Number of bytes cut from the end of the source before the operation
+	ShortSource int
+	// Number of bytes cut from the end of the destination before the operation
+	ShortDestination int
+	// Bit offsets in the source to flip before the operation, counted from the first bit of the source
+	// The source is copied first, so the caller's buffer is never modified
+	BitFlips []int
+	// Makes Compress store the data as if it did not compress
+	ForceStore bool
+}
+
+// Returns the source and destination with the faults applied
+func (f *Faults) apply(source []byte, destination []byte) ([]byte, []byte) {
+	if len(f.BitFlips) > 0 {
+		source = append([]byte(nil), source...)
+
+		for _, bit := range f.BitFlips {
+			if bit >= 0 && bit/8 < len(source) {
+				source[bit/8] ^= 1 << (bit % 8)
+			}
+		}
+	}
+
+	source = source[:len(source)-min(max(f.ShortSource, 0), len(source))]
+	destination = destination[:len(destination)-min(max(f.ShortDestination, 0), len(destination))]
+
+	return source, destination
+}
@@ -0,0 +1,240 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// The suffix of compressed files in the file systems wrapped by FS
+const FS_SUFFIX = ".doboz"
+
+type dobozFS struct {
+	fsys fs.FS
+
+	d *Decompressor
+
+	mu    sync.Mutex // guards the cache
+	cache map[string]*fsEntry
+}
+
+type fsEntry struct {
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// Wraps a file system, typically an embed.FS, which holds files compressed with doboz under a FS_SUFFIX suffix
+// The compressed files are exposed decompressed under their original names, and are listed so in directories
+// Each file is decompressed when it is first opened and kept in memory afterwards, other files are passed through
+// Failures are not kept, so a file which failed to read or decompress is tried again when it is opened next
+// If both a file and its compressed form exist, the compressed one is opened
+// Empty files are stored as empty compressed files, as doboz does not compress empty data
+// The returned file system is safe for concurrent use
+// Different files are decompressed concurrently, so the options must not include WithDecodeStats
+func FS(embedded fs.FS, options ...DecompressorOption) fs.FS {
+	return &dobozFS{fsys: embedded, d: NewDecompressor(options...), cache: make(map[string]*fsEntry)}
+}
+
+func (f *dobozFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	compressed, err := f.fsys.Open(name + FS_SUFFIX)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f.openPlain(name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	info, err := compressed.Stat()
+	if err != nil {
+		compressed.Close()
+		return nil, err
+	}
+
+	if info.IsDir() {
+		compressed.Close()
+		return f.openPlain(name)
+	}
+
+	data, err := f.load(name, compressed)
+	compressed.Close()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &fsFile{Reader: bytes.NewReader(data), info: fsFileInfo{FileInfo: info, name: path.Base(name), size: int64(len(data))}}, nil
+}
+
+// Opens a file which is not compressed, wrapping directories to list compressed files under their original names
+func (f *dobozFS) openPlain(name string) (fs.File, error) {
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir, ok := file.(fs.ReadDirFile); ok {
+		return &fsDir{ReadDirFile: dir, fsys: f, dir: name}, nil
+	}
+
+	return file, nil
+}
+
+// Returns the decompressed content of a file, decompressing it on the first successful call
+// Failed loads are not cached, so a file which failed to load is read and decompressed again when opened next
+func (f *dobozFS) load(name string, compressed fs.File) ([]byte, error) {
+	f.mu.Lock()
+	entry, ok := f.cache[name]
+	if !ok {
+		entry = &fsEntry{}
+		f.cache[name] = entry
+	}
+	f.mu.Unlock()
+
+	// Concurrent opens wait for the same load, and share its error if it fails
+	entry.once.Do(func() {
+		entry.data, entry.err = f.decompress(compressed)
+	})
+
+	if entry.err != nil {
+		f.mu.Lock()
+		if f.cache[name] == entry {
+			delete(f.cache, name)
+		}
+		f.mu.Unlock()
+	}
+
+	return entry.data, entry.err
+}
+
+// Reads and decompresses a compressed file
+func (f *dobozFS) decompress(compressed fs.File) ([]byte, error) {
+	source, err := io.ReadAll(compressed)
+	if err != nil || len(source) == 0 {
+		return nil, err
+	}
+
+	result, info := f.d.GetCompressionInfo(source)
+	if result != RESULT_OK {
+		return nil, result
+	}
+
+	data := make([]byte, info.UncompressedSize)
+	if result := f.d.Decompress(source, data); result != RESULT_OK {
+		return nil, result
+	}
+
+	return data, nil
+}
+
+// Returns the uncompressed size of a compressed file from its header, without decompressing it
+func (f *dobozFS) size(name string) (int64, error) {
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	header := make([]byte, wideHeaderSize)
+	n, err := io.ReadFull(file, header)
+	if n == 0 && err == io.EOF {
+		return 0, nil
+	} else if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, err
+	}
+
+	result, info := f.d.GetCompressionInfo(header[:n])
+	if result != RESULT_OK {
+		return 0, &fs.PathError{Op: "stat", Path: name, Err: result}
+	}
+
+	return int64(info.UncompressedSize), nil
+}
+
+// A decompressed file, which also supports seeking and reading at offsets for http.FileServer and similar users
+type fsFile struct {
+	*bytes.Reader
+	info fsFileInfo
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *fsFile) Close() error {
+	return nil
+}
+
+// The information of a compressed file, with its original name and uncompressed size
+type fsFileInfo struct {
+	fs.FileInfo
+	name string
+	size int64
+}
+
+func (i fsFileInfo) Name() string {
+	return i.name
+}
+
+func (i fsFileInfo) Size() int64 {
+	return i.size
+}
+
+func (i fsFileInfo) Sys() interface{} {
+	return nil
+}
+
+// A directory listing compressed files under their original names
+type fsDir struct {
+	fs.ReadDirFile
+	fsys *dobozFS
+	dir  string
+}
+
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries, err := d.ReadDirFile.ReadDir(n)
+
+	for i, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), FS_SUFFIX) {
+			entries[i] = fsDirEntry{DirEntry: entry, fsys: d.fsys, path: path.Join(d.dir, entry.Name())}
+		}
+	}
+
+	return entries, err
+}
+
+type fsDirEntry struct {
+	fs.DirEntry
+	fsys *dobozFS
+	path string
+}
+
+func (e fsDirEntry) Name() string {
+	return strings.TrimSuffix(e.DirEntry.Name(), FS_SUFFIX)
+}
+
+func (e fsDirEntry) Info() (fs.FileInfo, error) {
+	info, err := e.DirEntry.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := e.fsys.size(e.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return fsFileInfo{FileInfo: info, name: e.Name(), size: size}, nil
+}
+
+func (e fsDirEntry) String() string {
+	return fs.FormatDirEntry(e)
+}
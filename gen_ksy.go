@@ -0,0 +1,122 @@
+//go:build ignore
+
+// Generates doboz.ksy, a Kaitai Struct description of the block format, from the constants of the package
+// Run it with go generate
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/template"
+
+	doboz "github.com/razzie/go-doboz"
+)
+
+var ksyTemplate = template.Must(template.New("ksy").Parse(`# Code generated by gen_ksy.go; DO NOT EDIT.
+meta:
+  id: doboz
+  title: Doboz compressed block
+  file-extension: doboz
+  endian: le
+  bit-endian: le
+doc: |
+  A single block produced by the doboz compressor, format version {{.Version}}.
+  Compressed data is a sequence of {{.WordSize}}-byte little-endian control words, each followed by the literals
+  and matches it describes. Bit i of a control word is 0 for a literal and 1 for a match, and its highest set bit
+  is a guard bit marking the end of the list, so a control word describes at most {{.ControlWordBitCount}} tokens.
+  Matches are at least {{.MinMatchLength}} and at most {{.MaxMatchLength}} bytes long, and reach back at most
+  {{.MaxMatchOffset}} bytes. Their encoding is selected by the lowest bits of the first byte:{{range .MatchCodes}}
+  - {{.}}{{end}}
+  The last {{.TailLength}} bytes of the uncompressed data are always literals, and the compressed data ends with
+  {{.TrailingDummySize}} zero bytes.
+seq:
+  - id: attributes
+    type: attributes
+  - id: uncompressed_size
+    type:
+      switch-on: attributes.size_coded_size
+      cases:
+        1: u1
+        2: u2
+        4: u4
+        8: u8
+  - id: compressed_size
+    type:
+      switch-on: attributes.size_coded_size
+      cases:
+        1: u1
+        2: u2
+        4: u4
+        8: u8
+  - id: stored_data
+    size: uncompressed_size
+    if: attributes.is_stored
+  - id: compressed_data
+    size: compressed_size - header_size
+    if: not attributes.is_stored
+instances:
+  header_size:
+    value: 1 + 2 * attributes.size_coded_size
+types:
+  attributes:
+    seq:
+      - id: version
+        type: b3
+        valid: {{.Version}}
+      - id: size_coded_size_minus_one
+        type: b3
+      - id: reserved
+        type: b1
+      - id: is_stored
+        type: b1
+    instances:
+      size_coded_size:
+        value: size_coded_size_minus_one + 1
+`))
+
+type descriptor struct {
+	Version             int
+	WordSize            int
+	ControlWordBitCount int
+	MinMatchLength      int
+	MaxMatchLength      int
+	MaxMatchOffset      int
+	TailLength          int
+	TrailingDummySize   int
+	MatchCodes          []string
+}
+
+func main() {
+	output := flag.String("o", "doboz.ksy", "output file")
+	flag.Parse()
+
+	d := descriptor{
+		Version:             doboz.VERSION,
+		WordSize:            doboz.WORD_SIZE,
+		ControlWordBitCount: doboz.WORD_SIZE*8 - 1,
+		MinMatchLength:      doboz.MIN_MATCH_LENGTH,
+		MaxMatchLength:      doboz.MAX_MATCH_LENGTH,
+		MaxMatchOffset:      doboz.DICTIONARY_SIZE - 1,
+		TailLength:          doboz.TAIL_LENGTH,
+		TrailingDummySize:   doboz.TRAILING_DUMMY_SIZE,
+		MatchCodes: []string{
+			fmt.Sprintf("00: 1 byte, offset in bits 2-7, length %d", doboz.MIN_MATCH_LENGTH),
+			fmt.Sprintf("01: 2 bytes, offset in bits 2-15, length %d", doboz.MIN_MATCH_LENGTH),
+			fmt.Sprintf("10: 2 bytes, length - %d in bits 2-5, offset in bits 6-15", doboz.MIN_MATCH_LENGTH),
+			fmt.Sprintf("011: 3 bytes, length - %d in bits 3-7, offset in bits 8-23", doboz.MIN_MATCH_LENGTH),
+			fmt.Sprintf("111: 4 bytes, length - %d in bits 3-10, offset in bits 11-31", doboz.MIN_MATCH_LENGTH),
+		},
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := ksyTemplate.Execute(f, d); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,199 @@
+//go:build !dobozdecodeonly && !dobozencodeonly
+
+package doboz
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// Sizes which only fit in 8-byte size fields
+var wideSizes = []uint64{math.MaxUint32 + 1, 5 << 30, 1 << 40, math.MaxUint64}
+
+func TestDecodeWideHeader(t *testing.T) {
+	var d Decompressor
+
+	for _, size := range wideSizes {
+		for _, stored := range []bool{false, true} {
+			result, header, headerSize := d.decodeHeader(wideHeader(size, size-1, stored))
+			if result != RESULT_OK || headerSize != wideHeaderSize {
+				t.Errorf("%d bytes: decodeHeader = %v, %d", size, result, headerSize)
+				continue
+			}
+
+			if header.UncompressedSize != size || header.CompressedSize != size-1 || header.IsStored != stored {
+				t.Errorf("%d bytes: decoded %+v", size, header)
+			}
+		}
+	}
+}
+
+func TestEncodeWideHeader(t *testing.T) {
+	if uint64(MaxInt) <= math.MaxUint32 {
+		t.Skip("the maximum compressed size of blocks above 4 GB does not fit in an int")
+	}
+
+	var c Compressor
+	var d Decompressor
+
+	for _, size := range wideSizes[:3] {
+		header := Header{UncompressedSize: size, CompressedSize: size + wideHeaderSize, IsStored: true}
+		maxCompressedSize := int(size) + wideHeaderSize
+
+		if headerSize := getHeaderSize(maxCompressedSize); headerSize != wideHeaderSize {
+			t.Errorf("%d bytes: header size %d, want %d", size, headerSize, wideHeaderSize)
+		}
+
+		encoded := make([]byte, wideHeaderSize)
+		if result := c.encodeHeader(header, maxCompressedSize, encoded); result != RESULT_OK {
+			t.Errorf("%d bytes: encodeHeader: %v", size, result)
+			continue
+		}
+
+		if !bytes.Equal(encoded, wideHeader(header.UncompressedSize, header.CompressedSize, true)) {
+			t.Errorf("%d bytes: encoded header %x", size, encoded)
+		}
+
+		if result, decoded, _ := d.decodeHeader(encoded); result != RESULT_OK || decoded != header {
+			t.Errorf("%d bytes: decoded %+v, %v", size, decoded, result)
+		}
+	}
+}
+
+func TestWideHeaderStoredPayload(t *testing.T) {
+	var d Decompressor
+
+	payload := []byte("stored with 8-byte size fields")
+	block := append(wideHeader(uint64(len(payload)), uint64(wideHeaderSize+len(payload)), true), payload...)
+
+	if got, ok := d.StoredPayload(block); !ok || !bytes.Equal(got, payload) {
+		t.Errorf("StoredPayload = %q, %v", got, ok)
+	}
+
+	// Sizes beyond the source, including ones wrapping around when the header size is added, are rejected
+	for _, size := range append(wideSizes, uint64(len(payload)+1)) {
+		block := append(wideHeader(size, size, true), payload...)
+		if got, ok := d.StoredPayload(block); ok {
+			t.Errorf("%d bytes: StoredPayload accepted a block of %d bytes: %q", size, len(block), got)
+		}
+	}
+}
+
+func TestWideHeaderRoundTrip(t *testing.T) {
+	c := NewCompressor(WithWideHeader())
+	var d Decompressor
+
+	for _, input := range [][]byte{[]byte("a"), bytes.Repeat([]byte("wide header "), 1000), make([]byte, 100000)} {
+		compressed := make([]byte, c.MaxCompressedSize(len(input)))
+		result, compressedSize := c.Compress(input, compressed)
+		if result != RESULT_OK {
+			t.Fatalf("%d bytes: compression failed: %v", len(input), result)
+		}
+
+		result, info := d.GetCompressionInfo(compressed[:compressedSize])
+		if result != RESULT_OK || info.HeaderSize != wideHeaderSize || info.UncompressedSize != uint64(len(input)) {
+			t.Errorf("%d bytes: GetCompressionInfo = %v, %+v", len(input), result, info)
+		}
+
+		decompressed := make([]byte, len(input))
+		if result := d.Decompress(compressed[:compressedSize], decompressed); result != RESULT_OK || !bytes.Equal(decompressed, input) {
+			t.Errorf("%d bytes: decompression failed: %v", len(input), result)
+		}
+	}
+}
+
+func TestGetMaxCompressedSizeChecked(t *testing.T) {
+	type test struct {
+		size              int
+		result            Result
+		maxCompressedSize int
+		headerSize        int
+	}
+
+	tests := []test{
+		{-1, RESULT_ERROR_SIZE_LIMIT_EXCEEDED, 0, 0},
+		{0, RESULT_OK, narrowHeaderSize, 3},
+		{255 - narrowHeaderSize, RESULT_OK, 255, 3},
+		{256 - narrowHeaderSize, RESULT_OK, 256, 5},
+		{65535 - narrowHeaderSize, RESULT_OK, 65535, 5},
+		{65536 - narrowHeaderSize, RESULT_OK, 65536, narrowHeaderSize},
+		{MaxInt - narrowHeaderSize + 1, RESULT_ERROR_SIZE_LIMIT_EXCEEDED, 0, 0},
+		{MaxInt, RESULT_ERROR_SIZE_LIMIT_EXCEEDED, 0, 0},
+	}
+
+	if maxUint32 := uint64(math.MaxUint32); uint64(MaxInt) > maxUint32 {
+		// The size fields become 8 bytes wide once the maximum compressed size exceeds 4 GB
+		tests = append(tests,
+			test{int(maxUint32 - narrowHeaderSize), RESULT_OK, int(maxUint32), narrowHeaderSize},
+			test{int(maxUint32 - narrowHeaderSize + 1), RESULT_OK, int(maxUint32 + 1 - narrowHeaderSize + wideHeaderSize), wideHeaderSize},
+			test{int(maxUint32 + 1), RESULT_OK, int(maxUint32 + 1 + wideHeaderSize), wideHeaderSize},
+			test{MaxInt - wideHeaderSize, RESULT_OK, MaxInt, wideHeaderSize},
+			test{MaxInt - wideHeaderSize + 1, RESULT_ERROR_SIZE_LIMIT_EXCEEDED, 0, 0},
+			test{MaxInt - narrowHeaderSize, RESULT_ERROR_SIZE_LIMIT_EXCEEDED, 0, 0},
+		)
+	} else {
+		tests = append(tests,
+			test{MaxInt - wideHeaderSize, RESULT_OK, MaxInt - wideHeaderSize + narrowHeaderSize, narrowHeaderSize},
+			test{MaxInt - narrowHeaderSize, RESULT_OK, MaxInt, narrowHeaderSize},
+		)
+	}
+
+	for _, test := range tests {
+		result, maxCompressedSize := GetMaxCompressedSizeChecked(test.size)
+		if result != test.result || maxCompressedSize != test.maxCompressedSize {
+			t.Errorf("GetMaxCompressedSizeChecked(%d) = %v, %d, want %v, %d", test.size, result, maxCompressedSize,
+				test.result, test.maxCompressedSize)
+		}
+
+		if headerSize := GetHeaderSize(test.size); headerSize != test.headerSize {
+			t.Errorf("GetHeaderSize(%d) = %d, want %d", test.size, headerSize, test.headerSize)
+		}
+	}
+}
+
+func TestEncodeHeader(t *testing.T) {
+	maxUint32 := uint64(math.MaxUint32)
+
+	tests := []struct {
+		maxCompressedSize int
+		wide              bool
+		size              uint64
+		result            Result
+	}{
+		{255, false, 255, RESULT_OK},
+		{255, false, 256, RESULT_ERROR_SIZE_LIMIT_EXCEEDED},
+		{256, false, 256, RESULT_OK},
+		{256, false, 65535, RESULT_OK},
+		{256, false, 65536, RESULT_ERROR_SIZE_LIMIT_EXCEEDED},
+		{65536, false, 65536, RESULT_OK},
+		{65536, false, maxUint32, RESULT_OK},
+		{65536, false, maxUint32 + 1, RESULT_ERROR_SIZE_LIMIT_EXCEEDED},
+		{255, true, 256, RESULT_OK},
+		{65536, true, maxUint32 + 1, RESULT_OK},
+		{65536, true, math.MaxUint64, RESULT_OK},
+	}
+
+	var d Decompressor
+
+	for _, test := range tests {
+		c := &Compressor{wideHeader: test.wide}
+		header := Header{UncompressedSize: test.size, CompressedSize: test.size}
+
+		destination := make([]byte, wideHeaderSize)
+		if result := c.encodeHeader(header, test.maxCompressedSize, destination); result != test.result {
+			t.Errorf("encodeHeader of %d bytes with a bound of %d: %v, want %v", test.size, test.maxCompressedSize,
+				result, test.result)
+			continue
+		} else if result != RESULT_OK {
+			continue
+		}
+
+		wantHeaderSize := 1 + 2*c.getSizeCodedSize(test.maxCompressedSize)
+		if result, decoded, headerSize := d.decodeHeader(destination); result != RESULT_OK || decoded != header ||
+			headerSize != wantHeaderSize {
+			t.Errorf("%d bytes with a bound of %d: decoded %+v, %d, %v", test.size, test.maxCompressedSize, decoded,
+				headerSize, result)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+// Returns the number of bytes the compressed data must extend beyond the end of the uncompressed data
+// for in-place decompression with DecompressInPlace
+// To decompress in place, allocate a buffer of uncompressedSize + DecompressInPlaceMargin(uncompressedSize) bytes
+// and put the compressed data at its end
+func DecompressInPlaceMargin(uncompressedSize int) int {
+	// While decoding, the output advances faster than the input only by the matches, and the input can get ahead
+	// again by at most one control word per 31 literals, so the distance between the input read position and the
+	// output write position never drops by more than this below its final value
+	// Fast write operations may write up to a word beyond the output position, and the compressed data ends with
+	// the trailing dummy bytes
+	return WORD_SIZE*(uncompressedSize/CONTROL_WORD_BIT_COUNT+1) + 2*WORD_SIZE + TRAILING_DUMMY_SIZE
+}
+
+// Decompresses a block of data which is located inside the destination buffer, starting at sourceOffset
+// The uncompressed data is written to the beginning of the buffer, overwriting the compressed data
+// The compressed data must end at least DecompressInPlaceMargin bytes after the end of the uncompressed data
+// This operation is memory safe
+// On success, returns RESULT_OK
+func (d *Decompressor) DecompressInPlace(buffer []byte, sourceOffset int) Result {
+	if sourceOffset < 0 || sourceOffset > len(buffer) {
+		return RESULT_ERROR_BUFFER_TOO_SMALL
+	}
+
+	source := buffer[sourceOffset:]
+
+	// Decode the header
+	decodeHeaderResult, header, _ := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK {
+		return decodeHeaderResult
+	}
+
+	if uint64(len(source)) < header.CompressedSize || uint64(len(buffer)) < header.UncompressedSize {
+		return RESULT_ERROR_BUFFER_TOO_SMALL
+	}
+
+	// Check whether the output can never overtake the input
+	// The sum of the size and its margin may not fit in an int on 32-bit platforms
+	uncompressedSize := int(header.UncompressedSize)
+	if int64(sourceOffset)+int64(header.CompressedSize) < int64(uncompressedSize)+int64(DecompressInPlaceMargin(uncompressedSize)) {
+		return RESULT_ERROR_BUFFER_TOO_SMALL
+	}
+
+	return d.Decompress(source[:header.CompressedSize], buffer[:uncompressedSize])
+}
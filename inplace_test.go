@@ -0,0 +1,87 @@
+//go:build !dobozdecodeonly && !dobozencodeonly
+
+package doboz_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	doboz "github.com/razzie/go-doboz"
+	"github.com/razzie/go-doboz/dobozvectors"
+)
+
+// Returns inputs mixing runs, repeated phrases and random bytes, which make the input and output positions drift
+// apart in both directions
+func inPlaceInputs() [][]byte {
+	r := rand.New(rand.NewSource(1))
+
+	var inputs [][]byte
+	for _, size := range []int{1, 8, 9, 31, 32, 33, 100, 1000, 4096, 65536, 300000} {
+		random := make([]byte, size)
+		r.Read(random)
+
+		text := make([]byte, size)
+		for i := range text {
+			text[i] = "the quick brown fox jumps over the lazy dog"[r.Intn(8)+i%30]
+		}
+
+		mixed := make([]byte, size)
+		for i := 0; i < size; {
+			n := min(1+r.Intn(64), size-i)
+			if r.Intn(2) == 0 {
+				r.Read(mixed[i : i+n])
+			}
+			i += n
+		}
+
+		inputs = append(inputs, random, text, mixed, make([]byte, size))
+	}
+
+	for _, vector := range dobozvectors.All() {
+		inputs = append(inputs, vector.Uncompressed)
+	}
+
+	return inputs
+}
+
+// Places the block at the end of a buffer extending margin bytes beyond the uncompressed data and decodes it in place
+func decompressInPlace(t *testing.T, compressed []byte, uncompressedSize int, margin int) ([]byte, doboz.Result) {
+	t.Helper()
+
+	buffer := make([]byte, uncompressedSize+margin)
+	sourceOffset := len(buffer) - len(compressed)
+	if sourceOffset < 0 {
+		t.Fatalf("the block of %d bytes does not fit in a buffer of %d bytes", len(compressed), len(buffer))
+	}
+	copy(buffer[sourceOffset:], compressed)
+
+	var d doboz.Decompressor
+	return buffer[:uncompressedSize], d.DecompressInPlace(buffer, sourceOffset)
+}
+
+func TestDecompressInPlaceMargin(t *testing.T) {
+	var c doboz.Compressor
+
+	for _, input := range inPlaceInputs() {
+		compressed := make([]byte, doboz.GetMaxCompressedSize(len(input)))
+		result, compressedSize := c.Compress(input, compressed)
+		if result != doboz.RESULT_OK {
+			t.Fatalf("compressing %d bytes: %v", len(input), result)
+		}
+		compressed = compressed[:compressedSize]
+
+		margin := doboz.DecompressInPlaceMargin(len(input))
+
+		output, result := decompressInPlace(t, compressed, len(input), margin)
+		if result != doboz.RESULT_OK {
+			t.Errorf("%d bytes at the margin: %v", len(input), result)
+		} else if !bytes.Equal(output, input) {
+			t.Errorf("%d bytes at the margin: decoded data differs", len(input))
+		}
+
+		if _, result := decompressInPlace(t, compressed, len(input), margin-1); result != doboz.RESULT_ERROR_BUFFER_TOO_SMALL {
+			t.Errorf("%d bytes below the margin: got %v, want %v", len(input), result, doboz.RESULT_ERROR_BUFFER_TOO_SMALL)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !dobozdecodeonly && !tinygo
+
+package doboz
+
+import "runtime/pprof"
+
+// Returns the profiler labels of an operation, including the size bucket of its input
+func profileLabels(operation string, size int, extraLabels []string) pprof.LabelSet {
+	labels := append([]string{
+		"doboz.operation", operation,
+		"doboz.size", sizeBucket(size),
+	}, extraLabels...)
+
+	return pprof.Labels(labels...)
+}
+
+// Returns a coarse size class, so profiles of different block sizes can be told apart
+func sizeBucket(size int) string {
+	switch {
+	case size < 4<<10:
+		return "<4KB"
+	case size < 64<<10:
+		return "<64KB"
+	case size < 1<<20:
+		return "<1MB"
+	case size < 16<<20:
+		return "<16MB"
+	default:
+		return ">=16MB"
+	}
+}
@@ -0,0 +1,103 @@
+//go:build !dobozdecodeonly && !dobozencodeonly
+
+package doboz
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// Returns a header with 8-byte size fields declaring the specified sizes
+func wideHeader(uncompressedSize uint64, compressedSize uint64, stored bool) []byte {
+	header := make([]byte, wideHeaderSize)
+
+	header[0] = (8 - 1) << 3
+	if stored {
+		header[0] |= 128
+	}
+
+	binary.LittleEndian.PutUint64(header[1:], uncompressedSize)
+	binary.LittleEndian.PutUint64(header[9:], compressedSize)
+
+	return header
+}
+
+func TestExceedsMaxSourceSize(t *testing.T) {
+	tests := []struct {
+		size int
+		want bool
+	}{
+		{0, false},
+		{MaxInt - wideHeaderSize - 1, false},
+		{MaxInt - wideHeaderSize, false},
+		{MaxInt - wideHeaderSize + 1, true},
+		{MaxInt, true},
+	}
+
+	for _, test := range tests {
+		if got := exceedsMaxSourceSize(test.size); got != test.want {
+			t.Errorf("exceedsMaxSourceSize(%d) = %v, want %v", test.size, got, test.want)
+		}
+	}
+}
+
+func TestExceedsLimits(t *testing.T) {
+	maxInt := uint64(MaxInt)
+
+	tests := []struct {
+		name             string
+		uncompressedSize uint64
+		compressedSize   uint64
+		options          []DecompressorOption
+		want             bool
+	}{
+		{"small", 100, 100, nil, false},
+		{"MaxUint32-1", math.MaxUint32 - 1, math.MaxUint32 - 1, nil, math.MaxUint32-1 > maxInt},
+		{"MaxUint32", math.MaxUint32, math.MaxUint32, nil, math.MaxUint32 > maxInt},
+		{"MaxUint32+1", math.MaxUint32 + 1, math.MaxUint32 + 1, nil, math.MaxUint32+1 > maxInt},
+		{"MaxInt", maxInt, maxInt, nil, false},
+		{"MaxInt+1", maxInt + 1, maxInt + 1, nil, true},
+		{"uncompressed MaxInt+1", maxInt + 1, 100, nil, true},
+		{"MaxUint64", math.MaxUint64, math.MaxUint64, nil, true},
+		{"compressed size at the bound", 100, 100 + wideHeaderSize, nil, false},
+		{"compressed size above the bound", 100, 100 + wideHeaderSize + 1, nil, true},
+		{"compressed size MaxInt", 100, maxInt, nil, true},
+
+		{"at the decoded size limit", 1000, 100, []DecompressorOption{WithMaxDecodedSize(1000)}, false},
+		{"above the decoded size limit", 1001, 100, []DecompressorOption{WithMaxDecodedSize(1000)}, true},
+		{"at the block size limit", 1000, 100, []DecompressorOption{WithMaxBlockSize(100)}, false},
+		{"above the block size limit", 1000, 101, []DecompressorOption{WithMaxBlockSize(100)}, true},
+		{"MaxUint32+1 below the limits", math.MaxUint32 + 1, math.MaxUint32 + 1,
+			[]DecompressorOption{WithMaxDecodedSize(math.MaxUint64), WithMaxBlockSize(math.MaxUint64)}, math.MaxUint32+1 > maxInt},
+	}
+
+	for _, test := range tests {
+		d := NewDecompressor(test.options...)
+
+		result, header, _ := d.decodeHeader(wideHeader(test.uncompressedSize, test.compressedSize, false))
+		if result != RESULT_OK {
+			t.Errorf("%s: decodeHeader: %v", test.name, result)
+			continue
+		}
+
+		if header.UncompressedSize != test.uncompressedSize || header.CompressedSize != test.compressedSize {
+			t.Errorf("%s: decoded sizes %d and %d, want %d and %d", test.name, header.UncompressedSize,
+				header.CompressedSize, test.uncompressedSize, test.compressedSize)
+		}
+
+		if got := d.exceedsLimits(header); got != test.want {
+			t.Errorf("%s: exceedsLimits = %v, want %v", test.name, got, test.want)
+		}
+
+		// GetCompressionInfo must reject the block before the caller allocates a buffer for it
+		want := RESULT_OK
+		if test.want {
+			want = RESULT_ERROR_SIZE_LIMIT_EXCEEDED
+		}
+
+		if result, _ := d.GetCompressionInfo(wideHeader(test.uncompressedSize, test.compressedSize, false)); result != want {
+			t.Errorf("%s: GetCompressionInfo = %v, want %v", test.name, result, want)
+		}
+	}
+}
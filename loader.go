@@ -0,0 +1,112 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+)
+
+// Loads files on demand from a file system laid out like for FS, caching the decompressed files up to a byte budget
+// The least recently used files are evicted from the cache first, files larger than the budget are never cached
+// A Loader is safe for concurrent use, concurrent loads of the same file decompress it only once
+type Loader struct {
+	fsys fs.FS
+	d    *Decompressor
+
+	mu      sync.Mutex // guards the fields below
+	cache   *lruCache
+	loading map[string]*loaderCall
+}
+
+type loaderCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// Creates a Loader reading files from a file system, which caches at most budget bytes of decompressed files
+// Files compressed with doboz are stored under a FS_SUFFIX suffix, and are decompressed by a Decompressor with the
+// specified options, other files are loaded as they are
+// Files are decompressed concurrently, so the options must not include WithDecodeStats
+func NewLoader(fsys fs.FS, budget int, options ...DecompressorOption) *Loader {
+	return &Loader{
+		fsys:    fsys,
+		d:       NewDecompressor(options...),
+		cache:   newLRUCache(budget),
+		loading: make(map[string]*loaderCall),
+	}
+}
+
+// Returns the decompressed content of a file, from the cache if possible
+// The returned slice is shared with the cache and other callers, so it must not be modified
+// Returns the error of the file system, or the Result if decompression fails, wrapped in an fs.PathError
+func (l *Loader) Load(name string) ([]byte, error) {
+	l.mu.Lock()
+
+	if data, ok := l.cache.get(name); ok {
+		l.mu.Unlock()
+		return data, nil
+	}
+
+	// Wait for a load of the same file already in progress
+	if call, ok := l.loading[name]; ok {
+		l.mu.Unlock()
+		<-call.done
+		return call.data, call.err
+	}
+
+	call := &loaderCall{done: make(chan struct{})}
+	l.loading[name] = call
+	l.mu.Unlock()
+
+	call.data, call.err = l.read(name)
+
+	l.mu.Lock()
+	delete(l.loading, name)
+	if call.err == nil {
+		l.cache.add(name, call.data)
+	}
+	l.mu.Unlock()
+
+	close(call.done)
+	return call.data, call.err
+}
+
+// Returns the number of decompressed bytes held by the cache
+func (l *Loader) Size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.cache.size
+}
+
+// Removes every file from the cache
+func (l *Loader) Purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cache.purge()
+}
+
+func (l *Loader) read(name string) ([]byte, error) {
+	source, err := fs.ReadFile(l.fsys, name+FS_SUFFIX)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fs.ReadFile(l.fsys, name)
+	} else if err != nil || len(source) == 0 {
+		return nil, err
+	}
+
+	result, info := l.d.GetCompressionInfo(source)
+	if result != RESULT_OK {
+		return nil, &fs.PathError{Op: "load", Path: name, Err: result}
+	}
+
+	data := make([]byte, info.UncompressedSize)
+	if result := l.d.Decompress(source, data); result != RESULT_OK {
+		return nil, &fs.PathError{Op: "load", Path: name, Err: result}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,60 @@
+package doboz
+
+import "container/list"
+
+// A cache of byte slices holding at most budget bytes, which evicts the least recently used slices first
+// Slices larger than the budget are never cached
+// It is not safe for concurrent use, the types caching through it guard it with their own mutex
+type lruCache struct {
+	budget  int
+	entries map[string]*list.Element
+	lru     list.List // of *lruEntry, the most recently used first
+	size    int
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+func newLRUCache(budget int) *lruCache {
+	return &lruCache{budget: budget, entries: make(map[string]*list.Element)}
+}
+
+// Returns the slice cached under the key, marking it as the most recently used
+func (c *lruCache) get(key string) ([]byte, bool) {
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	return element.Value.(*lruEntry).value, true
+}
+
+// Adds a slice to the cache, evicting the least recently used slices to stay within the budget
+// A slice already cached under the key is kept
+func (c *lruCache) add(key string, value []byte) {
+	if _, ok := c.entries[key]; ok || len(value) > c.budget {
+		return
+	}
+
+	for c.size+len(value) > c.budget {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*lruEntry)
+
+		c.lru.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.value)
+	}
+
+	c.entries[key] = c.lru.PushFront(&lruEntry{key: key, value: value})
+	c.size += len(value)
+}
+
+// Removes every slice from the cache
+func (c *lruCache) purge() {
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
+}
@@ -0,0 +1,118 @@
+package doboz
+
+// The token model of a compressed block
+//
+// After the header, a compressed block is a sequence of control words, each one followed by the tokens it describes
+// A control word is a little-endian word whose bits, starting from the lowest one, tell whether the next token is
+// a literal (0), a single byte copied to the output as is, or a match (1), encoded with EncodeMatch
+// The highest bit of a control word is a guard bit, which marks the end of the bit list, so a control word describes
+// at most CONTROL_WORD_BIT_COUNT tokens, and the next control word follows the last of them
+// The guard bit simplifies and speeds up decoding: the literal bit must differ from it, so runs of literals can be
+// found by looking at the lowest bits only
+// The last control word may describe fewer tokens, and the block ends with TRAILING_DUMMY_SIZE zero bytes
+const (
+	CONTROL_WORD_BIT_COUNT int  = WORD_SIZE*8 - 1
+	CONTROL_WORD_GUARD_BIT uint = uint(1) << CONTROL_WORD_BIT_COUNT // the value of a control word with no tokens
+)
+
+// The decoding parameters of a match code, indexed by the lowest 3 bits of the code
+type LookupTable struct {
+	mask        uint // the mask for the entire encoded match
+	offsetShift byte
+	lengthMask  byte
+	lengthShift byte
+	size        int8 // the size of the encoded match in bytes
+}
+
+var matchLookupTable = [8]LookupTable{
+	{mask: 0xff, offsetShift: 2, lengthMask: 0, lengthShift: 0, size: 1},          // (0)00
+	{mask: 0xffff, offsetShift: 2, lengthMask: 0, lengthShift: 0, size: 2},        // (0)01
+	{mask: 0xffff, offsetShift: 6, lengthMask: 15, lengthShift: 2, size: 2},       // (0)10
+	{mask: 0xffffff, offsetShift: 8, lengthMask: 31, lengthShift: 3, size: 3},     // (0)11
+	{mask: 0xff, offsetShift: 2, lengthMask: 0, lengthShift: 0, size: 1},          // (1)00 = (0)00
+	{mask: 0xffff, offsetShift: 2, lengthMask: 0, lengthShift: 0, size: 2},        // (1)01 = (0)01
+	{mask: 0xffff, offsetShift: 6, lengthMask: 15, lengthShift: 2, size: 2},       // (1)10 = (0)10
+	{mask: 0xffffffff, offsetShift: 11, lengthMask: 255, lengthShift: 3, size: 4}, // 111
+}
+
+// The limits of a match code width
+type MatchCode struct {
+	Size      int // the size of the encoded match in bytes
+	MaxLength int // the longest match this code can encode
+	MaxOffset int // the largest offset this code can encode
+}
+
+// Returns the match codes from the shortest to the longest, as chosen by EncodeMatch
+// A match is encoded with the first code whose limits it fits in, so a code width can appear more than once, with
+// different trade-offs between the length and the offset
+func MatchCodes() []MatchCode {
+	// The last 4 entries of the lookup table repeat the first ones, except for the 4-byte code
+	codes := make([]MatchCode, 0, 5)
+	for _, i := range []int{0, 1, 2, 3, 7} {
+		entry := &matchLookupTable[i]
+		codes = append(codes, MatchCode{
+			Size:      int(entry.size),
+			MaxLength: int(entry.lengthMask) + MIN_MATCH_LENGTH,
+			MaxOffset: int(entry.mask >> entry.offsetShift),
+		})
+	}
+
+	return codes
+}
+
+// Encodes a match and returns its size in bytes, which is between 1 and WORD_SIZE
+// The length must be between MIN_MATCH_LENGTH and MAX_MATCH_LENGTH, and the offset must be below DICTIONARY_SIZE
+// If the destination is nil, nothing is written, only the size is computed
+// WARNING: May write up to WORD_SIZE bytes, even if the match is shorter!
+func EncodeMatch(match Match, destination []byte) int {
+	var word uint
+	var size int
+
+	lengthCode := uint(match.Length - MIN_MATCH_LENGTH)
+	offsetCode := uint(match.Offset)
+
+	if lengthCode == 0 && offsetCode < 64 {
+		word = offsetCode << 2 // 00
+		size = 1
+	} else if lengthCode == 0 && offsetCode < 16384 {
+		word = (offsetCode << 2) | 1 // 01
+		size = 2
+	} else if lengthCode < 16 && offsetCode < 1024 {
+		word = (offsetCode << 6) | (lengthCode << 2) | 2 // 10
+		size = 2
+	} else if lengthCode < 32 && offsetCode < 65536 {
+		word = (offsetCode << 8) | (lengthCode << 3) | 3 // 11
+		size = 3
+	} else {
+		word = (offsetCode << 11) | (lengthCode << 3) | 7 // 111
+		size = 4
+	}
+
+	if destination != nil {
+		FastWrite(destination, word, size)
+	}
+
+	return size
+}
+
+// Returns the size of the match in bytes when encoded with EncodeMatch
+func MatchCodedSize(match Match) int {
+	return EncodeMatch(match, nil)
+}
+
+// Decodes a match and returns its size in bytes
+// WARNING: Reads WORD_SIZE bytes, even if the match is shorter, so the source must have at least WORD_SIZE bytes!
+func DecodeMatch(source []byte) (Match, int) {
+	// Read the maximum number of bytes a match is coded in (4)
+	word := FastRead(source, WORD_SIZE)
+
+	// Compute the decoding lookup table entry index: the lowest 3 bits of the encoded match
+	entry := &matchLookupTable[word&7]
+
+	// Compute the match offset and length using the lookup table entry
+	var match Match
+	match.Offset = (int)((word & entry.mask) >> entry.offsetShift)
+	match.Length = (int)(((word >> uint(entry.lengthShift)) & uint(entry.lengthMask)) + MIN_MATCH_LENGTH)
+
+	return match, int(entry.size)
+}
@@ -0,0 +1,81 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var errMultiClosed = errors.New("doboz: write to closed MultiCompressor")
+
+// Compresses the data written to it once and writes the compressed block to several writers
+// The data is compressed as a single block, so it is kept in memory and the compressed block is written on Close
+type MultiCompressor struct {
+	c       *Compressor
+	writers []io.Writer
+
+	buffer blockBuffer
+}
+
+// Reports the writers which failed to receive the compressed block
+// Errors has an element for every writer, in the order they were passed to NewMultiCompressor, which is nil for the
+// writers that received the whole block
+type FanOutError struct {
+	Errors []error
+}
+
+func (e *FanOutError) Error() string {
+	failed := 0
+	var first error
+
+	for _, err := range e.Errors {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+
+	return fmt.Sprintf("doboz: writing to %d of %d destinations failed: %v", failed, len(e.Errors), first)
+}
+
+// Creates a MultiCompressor writing to every writer, compressing with a Compressor with the specified options
+func NewMultiCompressor(writers []io.Writer, options ...CompressorOption) *MultiCompressor {
+	return &MultiCompressor{c: NewCompressor(options...), writers: writers}
+}
+
+// Adds p to the data to compress
+// Nothing is written to the writers before Close
+func (m *MultiCompressor) Write(p []byte) (int, error) {
+	return m.buffer.write(p, errMultiClosed)
+}
+
+// Compresses the written data and writes the block to every writer, even if some of them fail
+// Writing no data at all writes nothing, none of the writers are closed
+// Returns the Result if compression fails, or a *FanOutError if any of the writers fails
+func (m *MultiCompressor) Close() error {
+	block, err := m.buffer.close(m.c)
+	if err != nil || block == nil {
+		return err
+	}
+
+	var fanOutError *FanOutError
+
+	for i, w := range m.writers {
+		if err := writeAll(w, block); err != nil {
+			if fanOutError == nil {
+				fanOutError = &FanOutError{Errors: make([]error, len(m.writers))}
+			}
+			fanOutError.Errors[i] = err
+		}
+	}
+
+	if fanOutError != nil {
+		return fanOutError
+	}
+
+	return nil
+}
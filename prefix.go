@@ -0,0 +1,50 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+// Decompresses only the first n bytes of a block
+// Decoding stops as soon as n bytes are produced, so the cost is proportional to n rather than to the block size
+// If the block is shorter than n bytes, the whole block is decompressed
+// This operation is memory safe
+// On success, returns RESULT_OK and the decompressed bytes
+func (d *Decompressor) DecompressPrefix(source []byte, n int) (Result, []byte) {
+	// Decode the header
+	decodeHeaderResult, header, headerSize := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK {
+		return decodeHeaderResult, nil
+	}
+
+	// Check whether the supplied buffer is large enough
+	if uint64(len(source)) < header.CompressedSize {
+		return RESULT_ERROR_BUFFER_TOO_SMALL, nil
+	}
+
+	if n < 0 {
+		n = 0
+	}
+
+	if uint64(n) > header.UncompressedSize {
+		n = int(header.UncompressedSize)
+	}
+
+	output := make([]byte, n)
+
+	// Decode tokens until the requested prefix is complete
+	tokens := d.newTokenReader(source, header, headerSize)
+
+	for tokens.outputIterator < n {
+		result, tok, ok := tokens.next()
+		if result != RESULT_OK {
+			return result, nil
+		}
+
+		if !ok {
+			break
+		}
+
+		tokens.copyToken(tok, output)
+	}
+
+	return RESULT_OK, output
+}
@@ -0,0 +1,36 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+// Decompresses a block of data, calling progress each time about regionSize more bytes of output are available
+// progress receives the prefix of the destination decompressed so far, which the caller can start using, such as
+// the first mip levels of a texture or the first chunks of audio, while the rest of the block is decompressed
+// The prefix grows by at least regionSize bytes between the calls, except for the last call, which receives the
+// whole uncompressed data; progress is not called again once decoding fails
+// The same checks apply as for Decompress, but the statistics and the tracer are not reported to, like for StepDecoder
+// On success, returns RESULT_OK
+func (d *Decompressor) DecompressProgressive(source []byte, destination []byte, regionSize int, progress func(decoded []byte)) Result {
+	result, s := d.NewStepDecoder(source, destination)
+	if result != RESULT_OK {
+		return result
+	}
+
+	reported := -1
+
+	for {
+		result, done := s.DecodeStep(regionSize)
+		if result != RESULT_OK {
+			return result
+		}
+
+		// The last step may only check the end of the block without producing output
+		if produced, _ := s.Progress(); produced > reported {
+			progress(destination[:produced])
+			reported = produced
+		}
+
+		if done {
+			return RESULT_OK
+		}
+	}
+}
@@ -0,0 +1,47 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+// Compresses the source in independent regions of regionSize bytes and returns the compression ratio
+// (uncompressed size / compressed size) of each region, the last region may be shorter
+// Ratios close to or below 1 mark regions which are not worth compressing
+// A regionSize of 0 or less means a single region
+func (c *Compressor) CompressibilityReport(source []byte, regionSize int) []float64 {
+	if regionSize <= 0 || regionSize > len(source) {
+		regionSize = len(source)
+	}
+
+	if regionSize == 0 {
+		return nil
+	}
+
+	// The regions are not part of a real compression, so keep them out of the statistics
+	defer c.suspendHooks()()
+
+	ratios := make([]float64, 0, (len(source)+regionSize-1)/regionSize)
+	destination := make([]byte, c.MaxCompressedSize(regionSize))
+
+	for regionStart := 0; regionStart < len(source); regionStart += regionSize {
+		region := source[regionStart:min(regionStart+regionSize, len(source))]
+
+		_, compressedSize := c.Compress(region, destination)
+		ratios = append(ratios, float64(len(region))/float64(compressedSize))
+	}
+
+	if c.wipe {
+		clear(destination)
+	}
+
+	return ratios
+}
+
+// Detaches the statistics, the tracer and the injected faults until the returned function is called
+// Used by analysis functions which compress data that does not end up in any output
+func (c *Compressor) suspendHooks() func() {
+	stats, tracer, faults := c.stats, c.tracer, c.faults
+	c.stats, c.tracer, c.faults = nil, nil, nil
+
+	return func() {
+		c.stats, c.tracer, c.faults = stats, tracer, faults
+	}
+}
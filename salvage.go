@@ -0,0 +1,83 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+// Decompresses as much of a possibly truncated block as possible
+// Unlike Decompress, it accepts a source shorter than the compressed size in the header and decodes every token
+// that is entirely present, returning RESULT_ERROR_TRUNCATED and the number of bytes recovered into the destination
+// The destination must be large enough for the whole uncompressed block
+// This operation is memory safe
+// On success, returns RESULT_OK and the uncompressed size
+func (d *Decompressor) Salvage(source []byte, destination []byte) (Result, int) {
+	// Decode the header
+	decodeHeaderResult, header, headerSize := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK {
+		return decodeHeaderResult, 0
+	}
+
+	if uint64(len(destination)) < header.UncompressedSize {
+		return RESULT_ERROR_BUFFER_TOO_SMALL, 0
+	}
+
+	// Nothing is missing, so decompress normally
+	if uint64(len(source)) >= header.CompressedSize {
+		if result := d.Decompress(source, destination); result != RESULT_OK {
+			return result, 0
+		}
+
+		return RESULT_OK, int(header.UncompressedSize)
+	}
+
+	// The payload of a stored block is copied as far as it is present
+	if header.IsStored {
+		return RESULT_ERROR_TRUNCATED, copy(destination[:header.UncompressedSize], source[headerSize:])
+	}
+
+	// Pad the missing input with zeros, so we can decode up to the truncation point with the usual checks
+	// Decoding stops at the first token reaching past the available input, and decoding a token reads at most two
+	// words past its start, so the padding never has to extend the source by more than that, whatever the header says
+	available := len(source)
+	padded := make([]byte, min(int(header.CompressedSize), available+2*WORD_SIZE))
+	copy(padded, source)
+	if d.wipe {
+		defer clear(padded)
+	}
+
+	tokens := d.newTokenReader(padded, header, headerSize)
+	outputSize := 0
+
+	for {
+		result, tok, ok := tokens.next()
+		if result != RESULT_OK {
+			// Errors caused by the padding mean that the input ended there
+			if tokens.inputIterator+2*WORD_SIZE > available {
+				return RESULT_ERROR_TRUNCATED, outputSize
+			}
+
+			return result, outputSize
+		}
+
+		if !ok {
+			break
+		}
+
+		// Check whether the token is entirely present in the input
+		if tokens.inputIterator > available {
+			// The literals before the truncation point are still valid
+			if !tok.isMatch && tok.inputPosition < available {
+				tok.length = available - tok.inputPosition
+				tokens.copyToken(tok, destination)
+				outputSize = tok.outputPosition + tok.length
+			}
+
+			return RESULT_ERROR_TRUNCATED, outputSize
+		}
+
+		tokens.copyToken(tok, destination)
+		outputSize = tok.outputPosition + tok.length
+	}
+
+	// Only the trailing dummy bytes are missing
+	return RESULT_OK, outputSize
+}
@@ -0,0 +1,127 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+import "sort"
+
+// Decompresses a block of data into a list of buffers, filling them in order as if they were a single destination
+// The buffers may have any size, such as fixed-size pages or arena slabs, and matches are copied across their
+// boundaries, so the concatenated buffers hold the same data Decompress would produce
+// The buffers must not overlap each other or the source
+// This operation is memory safe
+// On success, returns RESULT_OK
+func (d *Decompressor) DecompressScatter(source []byte, destinations [][]byte) Result {
+	// Decode the header
+	decodeHeaderResult, header, headerSize := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK {
+		return decodeHeaderResult
+	}
+
+	output := newScatterOutput(destinations)
+
+	// Check whether the supplied buffers are large enough
+	if uint64(len(source)) < header.CompressedSize || uint64(output.size) < header.UncompressedSize {
+		return RESULT_ERROR_BUFFER_TOO_SMALL
+	}
+
+	// In strict mode the supplied buffers must match the header exactly
+	if d.strict {
+		if uint64(len(source)) != header.CompressedSize {
+			return RESULT_ERROR_TRAILING_DATA
+		}
+
+		if uint64(output.size) != header.UncompressedSize {
+			return RESULT_ERROR_OUTPUT_SIZE_MISMATCH
+		}
+
+		if header.IsStored && header.CompressedSize != uint64(headerSize)+header.UncompressedSize {
+			return RESULT_ERROR_STREAM_SIZE_MISMATCH
+		}
+	}
+
+	tokens := d.newTokenReader(source, header, headerSize)
+
+	for {
+		result, tok, ok := tokens.next()
+		if result != RESULT_OK {
+			return result
+		}
+
+		if !ok {
+			break
+		}
+
+		if !tok.isMatch {
+			output.write(tok.outputPosition, source[tok.inputPosition:tok.inputPosition+tok.length])
+			continue
+		}
+
+		// Only corrupted data contains matches with an offset of 0, see copyToken
+		if tok.offset == 0 {
+			continue
+		}
+
+		// Overlapping matches repeat the last offset bytes, so copy in chunks of at most offset bytes
+		end := tok.outputPosition + tok.length
+		for position := tok.outputPosition; position < end; {
+			chunk := min(end-position, tok.offset)
+			output.copyWithin(position, position-tok.offset, chunk)
+			position += chunk
+		}
+	}
+
+	if d.strict && !tokens.endsExactly() {
+		return RESULT_ERROR_STREAM_SIZE_MISMATCH
+	}
+
+	return RESULT_OK
+}
+
+// A list of buffers addressed as a single output
+type scatterOutput struct {
+	buffers [][]byte
+	starts  []int // output position of the first byte of each buffer
+	size    int
+}
+
+func newScatterOutput(buffers [][]byte) *scatterOutput {
+	s := &scatterOutput{buffers: buffers, starts: make([]int, len(buffers))}
+
+	for i, buffer := range buffers {
+		s.starts[i] = s.size
+		s.size += len(buffer)
+	}
+
+	return s
+}
+
+// Returns the buffer holding an output position and the position inside that buffer
+// Empty buffers are skipped, because the last buffer starting at or before the position is chosen
+func (s *scatterOutput) locate(position int) ([]byte, int) {
+	i := sort.Search(len(s.starts), func(i int) bool { return s.starts[i] > position }) - 1
+	return s.buffers[i], position - s.starts[i]
+}
+
+// Writes data at an output position, across as many buffers as necessary
+func (s *scatterOutput) write(position int, data []byte) {
+	for len(data) > 0 {
+		buffer, offset := s.locate(position)
+		n := copy(buffer[offset:], data)
+		position += n
+		data = data[n:]
+	}
+}
+
+// Copies n bytes from an earlier output position to a later one, the two ranges must not overlap
+func (s *scatterOutput) copyWithin(destination int, source int, n int) {
+	for n > 0 {
+		destinationBuffer, destinationOffset := s.locate(destination)
+		sourceBuffer, sourceOffset := s.locate(source)
+
+		copied := copy(destinationBuffer[destinationOffset:min(destinationOffset+n, len(destinationBuffer))], sourceBuffer[sourceOffset:])
+		destination += copied
+		source += copied
+		n -= copied
+	}
+}
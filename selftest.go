@@ -0,0 +1,91 @@
+//go:build !dobozdecodeonly && !dobozencodeonly
+
+package doboz
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Checks at runtime that the encoder and decoder tables agree and that built-in samples survive a round trip
+// Every match length and offset at the boundaries of the match codes is encoded and decoded again, and the
+// word accesses are checked to be little-endian at every alignment, whatever the byte order of the platform
+// Intended as a cheap sanity check at process start, returns nil on success
+func SelfTest() error {
+	var c Compressor
+	var d Decompressor
+
+	// Check the byte order of the word accesses at every alignment
+	var buffer [2 * WORD_SIZE]byte
+	for alignment := 0; alignment < WORD_SIZE; alignment++ {
+		buffer = [2 * WORD_SIZE]byte{}
+		FastWrite(buffer[alignment:], 0x04030201, 4)
+
+		if buffer[alignment] != 1 || buffer[alignment+3] != 4 || FastRead(buffer[alignment:], 4) != 0x04030201 ||
+			FastRead(buffer[alignment:], 2) != 0x0201 {
+			return fmt.Errorf("doboz: self test: word accesses are not little-endian at alignment %d", alignment)
+		}
+	}
+
+	// Check the match codes at their boundaries
+	lengths := []int{MIN_MATCH_LENGTH, MIN_MATCH_LENGTH + 1, MIN_MATCH_LENGTH + 15, MIN_MATCH_LENGTH + 16,
+		MIN_MATCH_LENGTH + 31, MIN_MATCH_LENGTH + 32, MAX_MATCH_LENGTH}
+	offsets := []int{1, 63, 64, 1023, 1024, 16383, 16384, 65535, 65536, DICTIONARY_SIZE - 1}
+
+	var encoded [WORD_SIZE]byte
+
+	for _, length := range lengths {
+		for _, offset := range offsets {
+			match := Match{Length: length, Offset: offset}
+
+			encoded = [WORD_SIZE]byte{}
+			encodedSize := EncodeMatch(match, encoded[:])
+			decoded, decodedSize := DecodeMatch(encoded[:])
+
+			if decoded != match || decodedSize != encodedSize {
+				return fmt.Errorf("doboz: self test: match %+v encoded in %d bytes decodes to %+v in %d bytes",
+					match, encodedSize, decoded, decodedSize)
+			}
+		}
+	}
+
+	// Round trip the built-in samples
+	for _, sample := range selfTestSamples() {
+		compressed := make([]byte, GetMaxCompressedSize(len(sample)))
+
+		result, compressedSize := c.Compress(sample, compressed)
+		if result != RESULT_OK {
+			return fmt.Errorf("doboz: self test: compressing %d bytes: %w", len(sample), result)
+		}
+
+		decompressed := make([]byte, len(sample))
+		if result := d.Decompress(compressed[:compressedSize], decompressed); result != RESULT_OK {
+			return fmt.Errorf("doboz: self test: decompressing %d bytes: %w", len(sample), result)
+		}
+
+		if !bytes.Equal(decompressed, sample) {
+			return fmt.Errorf("doboz: self test: %d bytes decompress to different data", len(sample))
+		}
+	}
+
+	return nil
+}
+
+// Returns samples exercising literals, short and long matches, overlapping matches and stored blocks
+func selfTestSamples() [][]byte {
+	text := []byte("doboz doboz, doboz compresses; doboz decompresses quickly. ")
+	repeated := bytes.Repeat(text, 100)
+	zeros := make([]byte, 5000)
+
+	// A simple linear congruential generator gives reproducible incompressible bytes
+	random := make([]byte, 2000)
+	state := uint32(1)
+	for i := range random {
+		state = state*1664525 + 1013904223
+		random[i] = byte(state >> 24)
+	}
+
+	mixed := append(append(append([]byte(nil), random[:500]...), repeated[:1000]...), random[:500]...)
+
+	return [][]byte{[]byte("x"), []byte("abcdefghi"), text, repeated, zeros, random, mixed}
+}
@@ -0,0 +1,49 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+type Format int
+
+const (
+	FORMAT_UNKNOWN Format = iota
+	FORMAT_BLOCK          // a raw block produced by Compressor.Compress
+)
+
+// Tells whether the beginning of a buffer looks like doboz compressed data
+// Returns the detected format and the version of the encoding format, or FORMAT_UNKNOWN
+// Only the header is inspected, so a positive answer does not guarantee that the data can be decompressed
+func Sniff(prefix []byte) (Format, int) {
+	if len(prefix) < 1 {
+		return FORMAT_UNKNOWN, 0
+	}
+
+	attributes := uint(prefix[0])
+
+	// The unused attribute bit is always zero
+	if attributes&64 != 0 {
+		return FORMAT_UNKNOWN, 0
+	}
+
+	var d Decompressor
+	decodeHeaderResult, header, headerSize := d.decodeHeader(prefix)
+
+	if decodeHeaderResult != RESULT_OK {
+		return FORMAT_UNKNOWN, 0
+	}
+
+	// The sizes must be consistent with the way the compressor encodes blocks
+	// The sizes are compared by subtraction, because the header may declare sizes close to the largest uint64
+	if header.IsStored {
+		if header.CompressedSize < uint64(headerSize) || header.CompressedSize-uint64(headerSize) != header.UncompressedSize {
+			return FORMAT_UNKNOWN, 0
+		}
+	} else {
+		// The minimum size is always larger than the header, even with 8-byte size fields
+		maxHeaderSize := max(narrowHeaderSize, headerSize)
+		if header.CompressedSize < uint64(headerSize+WORD_SIZE+TRAILING_DUMMY_SIZE) || header.CompressedSize-uint64(maxHeaderSize) > header.UncompressedSize {
+			return FORMAT_UNKNOWN, 0
+		}
+	}
+
+	return FORMAT_BLOCK, header.Version
+}
@@ -0,0 +1,110 @@
+package doboz
+
+import "math/bits"
+
+// Statistics about the last Compress call, see WithCompressStats
+type CompressStats struct {
+	LiteralCount       int     // number of literals encoded
+	MatchCount         int     // number of matches encoded
+	AverageMatchLength float64 // average length of the encoded matches
+	AverageMatchOffset float64 // average offset of the encoded matches
+	IsStored           bool    // the data did not compress, so it was stored instead
+	HeaderSize         int     // size of the block header in bytes
+	UncompressedSize   int
+	CompressedSize     int
+	Ratio              float64 // uncompressed size / compressed size
+
+	Histograms *CompressHistograms // only filled if the compressor was created with WithCompressHistograms
+
+	matchLengthSum int
+	matchOffsetSum int
+	literalRun     int
+}
+
+// Distributions of the encoded tokens, see WithCompressHistograms
+type CompressHistograms struct {
+	MatchLengths    [MAX_MATCH_LENGTH + 1]int // indexed by match length
+	MatchOffsets    [22]int                   // indexed by the bit length of the offset, so entry i counts offsets in [2^(i-1), 2^i)
+	MatchCodedSizes [WORD_SIZE + 1]int        // indexed by the number of bytes the match is encoded in
+	LiteralRuns     [bits.UintSize + 1]int    // indexed by the bit length of the number of consecutive literals
+}
+
+func (s *CompressStats) reset(histograms bool) {
+	h := s.Histograms
+	*s = CompressStats{}
+
+	if histograms {
+		if h == nil {
+			h = new(CompressHistograms)
+		} else {
+			*h = CompressHistograms{}
+		}
+		s.Histograms = h
+	}
+}
+
+func (s *CompressStats) addLiteral() {
+	s.LiteralCount++
+	s.literalRun++
+}
+
+func (s *CompressStats) addMatch(match Match, codedSize int) {
+	s.MatchCount++
+	s.matchLengthSum += match.Length
+	s.matchOffsetSum += match.Offset
+
+	if s.Histograms != nil {
+		s.flushLiteralRun()
+		s.Histograms.MatchLengths[match.Length]++
+		s.Histograms.MatchOffsets[bits.Len(uint(match.Offset))]++
+		s.Histograms.MatchCodedSizes[codedSize]++
+	}
+}
+
+func (s *CompressStats) flushLiteralRun() {
+	if s.literalRun > 0 {
+		s.Histograms.LiteralRuns[bits.Len(uint(s.literalRun))]++
+		s.literalRun = 0
+	}
+}
+
+// Fills in the block level statistics once the block is complete
+func (s *CompressStats) finish(header Header, headerSize int) {
+	if header.IsStored {
+		// The literals and matches of the abandoned compression attempt are not in the output
+		s.reset(s.Histograms != nil)
+	} else if s.Histograms != nil {
+		s.flushLiteralRun()
+	}
+
+	s.IsStored = header.IsStored
+	s.HeaderSize = headerSize
+	s.UncompressedSize = int(header.UncompressedSize)
+	s.CompressedSize = int(header.CompressedSize)
+	s.Ratio = float64(s.UncompressedSize) / float64(s.CompressedSize)
+
+	if s.MatchCount > 0 {
+		s.AverageMatchLength = float64(s.matchLengthSum) / float64(s.MatchCount)
+		s.AverageMatchOffset = float64(s.matchOffsetSum) / float64(s.MatchCount)
+	}
+}
+
+// Statistics accumulated over all Decompress calls of a decompressor, see WithDecodeStats
+type DecodeStats struct {
+	BlockCount        int // number of compressed blocks decoded
+	StoredBlockCount  int // number of stored blocks copied
+	ControlWordCount  int // number of control words read
+	LiteralCount      int // number of literals decoded, including the contents of stored blocks
+	MatchCount        int // number of matches decoded
+	MatchBytes        int // number of bytes produced by matches
+	OverlapMatchBytes int // number of bytes produced by matches whose offset is less than the word size, which need a slower copy
+}
+
+func (s *DecodeStats) addMatch(match Match) {
+	s.MatchCount++
+	s.MatchBytes += match.Length
+
+	if match.Offset < WORD_SIZE {
+		s.OverlapMatchBytes += match.Length
+	}
+}
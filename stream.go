@@ -0,0 +1,89 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+import "io"
+
+// The window of recent output kept by DecompressTo
+// Matches never reach further back than DICTIONARY_SIZE, so older output can be handed to the writer
+// With WithMaxWindowSize, the window is twice the configured size plus room for a match instead
+const STREAM_WINDOW_SIZE = 2 * DICTIONARY_SIZE
+
+// Decompresses a block of data into a writer
+// Only a fixed size window of recent output is kept in memory, so memory use does not depend on the uncompressed size
+// This operation is memory safe
+// Returns nil on success, the Result on decoding errors, or the error of the writer
+// A writer accepting fewer bytes than given without reporting an error yields io.ErrShortWrite
+func (d *Decompressor) DecompressTo(w io.Writer, source []byte) error {
+	// Decode the header
+	decodeHeaderResult, header, headerSize := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK {
+		return decodeHeaderResult
+	}
+
+	// Check whether the supplied buffer is large enough
+	if uint64(len(source)) < header.CompressedSize {
+		return RESULT_ERROR_BUFFER_TOO_SMALL
+	}
+
+	// Stored data can be written directly
+	if header.IsStored {
+		payload, ok := d.StoredPayload(source)
+		if !ok {
+			return RESULT_ERROR_CORRUPTED_DATA
+		}
+
+		return writeAll(w, payload)
+	}
+
+	windowSize := d.windowSize()
+	window := make([]byte, min(d.streamWindowSize(), int(header.UncompressedSize)))
+	if d.wipe {
+		defer clear(window)
+	}
+	windowBase := 0    // output position of the first byte in the window
+	windowFlushed := 0 // number of bytes in the window already written
+
+	tokens := d.newTokenReader(source, header, headerSize)
+
+	for {
+		result, tok, ok := tokens.next()
+		if result != RESULT_OK {
+			return result
+		}
+
+		if !ok {
+			break
+		}
+
+		// Make room for the token by writing the window and keeping only the bytes matches may refer to
+		if tok.outputPosition+tok.length-windowBase > len(window) {
+			position := tok.outputPosition - windowBase
+
+			if err := writeAll(w, window[windowFlushed:position]); err != nil {
+				return err
+			}
+
+			kept := copy(window, window[position-windowSize:position])
+			windowBase += position - kept
+			windowFlushed = kept
+		}
+
+		tok.outputPosition -= windowBase
+		tokens.copyToken(tok, window)
+	}
+
+	// Write the rest of the window
+	return writeAll(w, window[windowFlushed:tokens.outputIterator-windowBase])
+}
+
+// Returns the size of the window kept by DecompressTo
+// It holds the bytes matches may refer to and the token being decoded, and is filled further before being written
+func (d *Decompressor) streamWindowSize() int {
+	if windowSize := d.windowSize(); windowSize < DICTIONARY_SIZE {
+		return 2*windowSize + MAX_MATCH_LENGTH
+	}
+
+	return STREAM_WINDOW_SIZE
+}
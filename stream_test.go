@@ -0,0 +1,141 @@
+//go:build !dobozdecodeonly && !dobozencodeonly
+
+package doboz_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	doboz "github.com/razzie/go-doboz"
+	"github.com/razzie/go-doboz/dobozvectors"
+)
+
+// The size of the input, which makes DecompressTo write its window and move it a few times
+const streamInputSize = 2*doboz.STREAM_WINDOW_SIZE + 12345
+
+// Returns an input made of random chunks repeated a few times, which compresses quickly
+func streamInput(size int) []byte {
+	r := rand.New(rand.NewSource(1))
+
+	input := make([]byte, 0, size)
+	chunk := make([]byte, 512)
+	for len(input) < size {
+		r.Read(chunk)
+		for i := 0; i < 4; i++ {
+			input = append(input, chunk...)
+		}
+	}
+
+	return input[:size]
+}
+
+func compressStreamInput(t *testing.T, input []byte) []byte {
+	t.Helper()
+
+	var c doboz.Compressor
+	compressed := make([]byte, doboz.GetMaxCompressedSize(len(input)))
+	result, compressedSize := c.Compress(input, compressed)
+	if result != doboz.RESULT_OK {
+		t.Fatalf("compressing %d bytes: %v", len(input), result)
+	}
+
+	return compressed[:compressedSize]
+}
+
+// A writer accepting limit bytes, then failing with err or, if err is nil, accepting fewer bytes than given
+type failingWriter struct {
+	bytes.Buffer
+	limit int
+	err   error
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.Len()+len(p) <= w.limit {
+		return w.Buffer.Write(p)
+	}
+
+	n, _ := w.Buffer.Write(p[:w.limit-w.Len()])
+	return n, w.err
+}
+
+var streamBlock struct {
+	once       sync.Once
+	input      []byte
+	compressed []byte
+}
+
+// Returns the stream input and its compressed block, compressing it only once for all the tests
+func streamInputBlock(t *testing.T) ([]byte, []byte) {
+	streamBlock.once.Do(func() {
+		streamBlock.input = streamInput(streamInputSize)
+		streamBlock.compressed = compressStreamInput(t, streamBlock.input)
+	})
+
+	return streamBlock.input, streamBlock.compressed
+}
+
+func TestDecompressTo(t *testing.T) {
+	input, compressed := streamInputBlock(t)
+
+	inputs := map[string][]byte{"stream": input}
+	blocks := map[string][]byte{"stream": compressed}
+	for _, vector := range dobozvectors.All() {
+		inputs[vector.Name] = vector.Uncompressed
+		blocks[vector.Name] = vector.Compressed
+	}
+
+	var d doboz.Decompressor
+	for name, block := range blocks {
+		var output bytes.Buffer
+		if err := d.DecompressTo(&output, block); err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if !bytes.Equal(output.Bytes(), inputs[name]) {
+			t.Errorf("%s: decoded data differs", name)
+		}
+	}
+}
+
+// The number of bytes after which the writers of the tests stop accepting data
+// They cover stopping before anything is written, inside the first window and after the window moved
+var streamLimits = []int{0, 1, doboz.DICTIONARY_SIZE, doboz.STREAM_WINDOW_SIZE + 1, streamInputSize - 1}
+
+func TestDecompressToTruncateWriter(t *testing.T) {
+	input, compressed := streamInputBlock(t)
+
+	var d doboz.Decompressor
+	for _, limit := range streamLimits {
+		var output bytes.Buffer
+		if err := d.DecompressTo(iotest.TruncateWriter(&output, int64(limit)), compressed); err != nil {
+			t.Errorf("truncated at %d bytes: %v", limit, err)
+		} else if !bytes.Equal(output.Bytes(), input[:limit]) {
+			t.Errorf("truncated at %d bytes: written data differs", limit)
+		}
+	}
+}
+
+func TestDecompressToWriterError(t *testing.T) {
+	input, compressed := streamInputBlock(t)
+	errWrite := errors.New("write failed")
+
+	var d doboz.Decompressor
+	for _, limit := range streamLimits {
+		for _, writerErr := range []error{errWrite, nil} {
+			want := writerErr
+			if want == nil {
+				want = io.ErrShortWrite
+			}
+
+			w := &failingWriter{limit: limit, err: writerErr}
+			if err := d.DecompressTo(w, compressed); !errors.Is(err, want) {
+				t.Errorf("failing after %d bytes: got %v, want %v", limit, err, want)
+			} else if !bytes.Equal(w.Bytes(), input[:limit]) {
+				t.Errorf("failing after %d bytes: written data differs", limit)
+			}
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build noasm
+
+package doboz
+
+// Conversions between strings and byte slices used by the string helpers, copying the bytes
+
+const pureGo = true
+
+func stringBytes(s string) []byte {
+	return []byte(s)
+}
+
+func bytesString(b []byte) string {
+	return string(b)
+}
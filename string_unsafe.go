@@ -0,0 +1,20 @@
+//go:build !noasm
+
+package doboz
+
+import "unsafe"
+
+// Conversions between strings and byte slices used by the string helpers
+// They share the memory instead of copying it, which is safe because the bytes of a string are only ever read, and
+// a converted byte slice is never modified afterwards
+// Builds with the noasm build tag copy the bytes instead
+
+const pureGo = false
+
+func stringBytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+func bytesString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
@@ -0,0 +1,54 @@
+//go:build !dobozdecodeonly
+
+package doboz
+
+import (
+	"errors"
+	"io"
+)
+
+var errTeeClosed = errors.New("doboz: write to closed TeeCompressor")
+
+// Writes the data written to it unmodified to one writer, and compressed to another one
+// The data is compressed as a single block, so it is kept in memory and the compressed block is written on Close
+type TeeCompressor struct {
+	c          *Compressor
+	raw        io.Writer
+	compressed io.Writer
+
+	buffer blockBuffer
+}
+
+// Creates a TeeCompressor writing to the raw and the compressed writer, compressing with a Compressor with the
+// specified options
+func NewTeeCompressor(raw io.Writer, compressed io.Writer, options ...CompressorOption) *TeeCompressor {
+	return &TeeCompressor{c: NewCompressor(options...), raw: raw, compressed: compressed}
+}
+
+// Writes p to the raw writer and adds it to the data to compress
+// Data the raw writer fails to accept is not compressed either, so both outputs hold the same data
+func (t *TeeCompressor) Write(p []byte) (int, error) {
+	if t.buffer.closed {
+		return 0, errTeeClosed
+	}
+
+	n, err := t.raw.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+
+	t.buffer.write(p[:n], errTeeClosed)
+	return n, err
+}
+
+// Compresses the written data and writes the block to the compressed writer
+// Writing no data at all writes nothing, neither writer is closed
+// Returns the Result if compression fails, or the error of the compressed writer
+func (t *TeeCompressor) Close() error {
+	block, err := t.buffer.close(t.c)
+	if err != nil || block == nil {
+		return err
+	}
+
+	return writeAll(t.compressed, block)
+}
@@ -0,0 +1,196 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+// A decoded unit of the compressed stream: either a run of literals or a match
+type token struct {
+	isMatch        bool
+	inputPosition  int // position of the literals or the encoded match in the source
+	outputPosition int // position of the decoded bytes in the output
+	length         int // number of decoded bytes
+	offset         int // match offset, 0 for literals
+}
+
+// Walks the tokens of a compressed block without producing any output
+// It performs the same checks as Decompress, so it can be used to drive alternative decoding strategies
+type tokenReader struct {
+	d      *Decompressor
+	source []byte
+
+	inputIterator  int
+	inputEnd       int
+	outputIterator int
+	outputEnd      int
+	outputTail     int
+
+	controlWord    uint
+	isStored       bool
+	tail           bool
+	done           bool
+	controlWordHit bool // set if the last call to next read a new control word
+}
+
+// Prepares walking the tokens of a block whose header has already been decoded
+// The source must contain at least header.CompressedSize bytes
+func (d *Decompressor) newTokenReader(source []byte, header Header, headerSize int) *tokenReader {
+	t := &tokenReader{
+		d:             d,
+		source:        source,
+		inputIterator: headerSize,
+		inputEnd:      int(header.CompressedSize),
+		outputEnd:     int(header.UncompressedSize),
+		controlWord:   1,
+		isStored:      header.IsStored,
+	}
+
+	if t.outputEnd > TAIL_LENGTH {
+		t.outputTail = t.outputEnd - TAIL_LENGTH
+	}
+
+	return t
+}
+
+// Returns the next token
+// When there are no more tokens, returns RESULT_OK and false
+func (t *tokenReader) next() (Result, token, bool) {
+	var tok token
+	t.controlWordHit = false
+
+	if t.done {
+		return RESULT_OK, tok, false
+	}
+
+	// Stored data is a single run of literals
+	if t.isStored {
+		t.done = true
+
+		if t.inputIterator+t.outputEnd > len(t.source) {
+			return RESULT_ERROR_CORRUPTED_DATA, tok, false
+		}
+
+		tok.inputPosition = t.inputIterator
+		tok.length = t.outputEnd
+		t.inputIterator += t.outputEnd
+		t.outputIterator = t.outputEnd
+		return RESULT_OK, tok, tok.length > 0
+	}
+
+	// Once in the tail, only single literals follow
+	if !t.tail {
+		// In order to decode the next literal/match, we have to read up to 8 bytes (2 words)
+		if t.inputIterator+2*WORD_SIZE > t.inputEnd {
+			return RESULT_ERROR_CORRUPTED_DATA, tok, false
+		}
+
+		t.readControlWord()
+
+		if (t.controlWord&1) == 0 && t.outputIterator >= t.outputTail {
+			// We have reached the tail, we cannot output literals in runs anymore
+			t.tail = true
+		}
+	}
+
+	if t.tail {
+		if t.outputIterator == t.outputEnd {
+			t.done = true
+			return RESULT_OK, tok, false
+		}
+
+		// In order to decode the next literal, we have to read up to 5 bytes
+		if t.inputIterator+WORD_SIZE+1 > t.inputEnd {
+			return RESULT_ERROR_CORRUPTED_DATA, tok, false
+		}
+
+		t.readControlWord()
+
+		tok.inputPosition = t.inputIterator
+		tok.outputPosition = t.outputIterator
+		tok.length = 1
+
+		t.inputIterator++
+		t.outputIterator++
+		t.controlWord >>= 1
+		return RESULT_OK, tok, true
+	}
+
+	if (t.controlWord & 1) == 0 {
+		// A run of up to 4 literals
+		runLength := int(literalRunLengthTable[t.controlWord&0xf])
+
+		tok.inputPosition = t.inputIterator
+		tok.outputPosition = t.outputIterator
+		tok.length = runLength
+
+		t.inputIterator += runLength
+		t.outputIterator += runLength
+		t.controlWord >>= runLength
+		return RESULT_OK, tok, true
+	}
+
+	// A match
+	match, matchSize := t.d.decodeMatch(t.source[t.inputIterator:])
+
+	if t.outputIterator-match.Offset < 0 || t.outputIterator+match.Length > t.outputTail {
+		return RESULT_ERROR_CORRUPTED_DATA, tok, false
+	}
+
+	if t.d.exceedsMaxWindowSize(match) {
+		return RESULT_ERROR_SIZE_LIMIT_EXCEEDED, tok, false
+	}
+
+	tok.isMatch = true
+	tok.inputPosition = t.inputIterator
+	tok.outputPosition = t.outputIterator
+	tok.length = match.Length
+	tok.offset = match.Offset
+
+	t.inputIterator += matchSize
+	t.outputIterator += match.Length
+	t.controlWord >>= 1
+	return RESULT_OK, tok, true
+}
+
+// Reads a new control word if the current one is empty
+func (t *tokenReader) readControlWord() {
+	if t.controlWord == 1 {
+		t.controlWord = FastRead(t.source[t.inputIterator:], WORD_SIZE)
+		t.inputIterator += WORD_SIZE
+		t.controlWordHit = true
+	}
+}
+
+// Checks whether the stream ended exactly where the header says, see WithStrictValidation
+// Must be called after next reported the end of the tokens
+func (t *tokenReader) endsExactly() bool {
+	if t.isStored {
+		return t.inputIterator == t.inputEnd
+	}
+
+	return t.inputIterator+TRAILING_DUMMY_SIZE == t.inputEnd && t.controlWord&(t.controlWord-1) == 0
+}
+
+// Writes the decoded bytes of a token into the output, which must hold all the data preceding the token
+// Bytes beyond the end of the output are dropped
+func (t *tokenReader) copyToken(tok token, output []byte) {
+	if tok.outputPosition >= len(output) {
+		return
+	}
+
+	end := min(tok.outputPosition+tok.length, len(output))
+
+	if !tok.isMatch {
+		copy(output[tok.outputPosition:end], t.source[tok.inputPosition:])
+		return
+	}
+
+	// Only corrupted data contains matches with an offset of 0, which Decompress fills with whatever the destination
+	// already held, so there is nothing sensible to copy
+	if tok.offset == 0 {
+		return
+	}
+
+	// Overlapping matches repeat the last offset bytes, so copy in chunks of at most offset bytes
+	for position := tok.outputPosition; position < end; {
+		position += copy(output[position:end], output[position-tok.offset:position])
+	}
+}
@@ -0,0 +1,14 @@
+package doboz
+
+// Receives every step of encoding or decoding, see WithCompressTracer and WithDecodeTracer
+// Positions of literals and matches are in the uncompressed data, positions of control words are in the compressed data
+// The decoder reports control words when it reads them, before their literals and matches,
+// while the encoder reports them when they are flushed, after their literals and matches
+type Tracer interface {
+	// Called once per block: by the decoder before decoding it, by the encoder after encoding it
+	// If the encoder falls back to storing the data, the tokens reported before belong to the abandoned attempt
+	OnBlock(header Header)
+	OnControlWord(position int, controlWord uint)
+	OnLiteral(position int, literal byte)
+	OnMatch(position int, match Match)
+}
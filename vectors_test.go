@@ -0,0 +1,65 @@
+//go:build !dobozdecodeonly && !dobozencodeonly
+
+package doboz_test
+
+import (
+	"bytes"
+	"testing"
+
+	doboz "github.com/razzie/go-doboz"
+	"github.com/razzie/go-doboz/dobozvectors"
+)
+
+// Checks the vectors with the word accesses and conversions of the build, so running the tests once more with the
+// noasm build tag covers the portable implementation
+func TestVectors(t *testing.T) {
+	if err := doboz.SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Generate compresses every input with the options of its vector
+	generated := make(map[string]dobozvectors.Vector)
+	for _, vector := range dobozvectors.Generate() {
+		generated[vector.Name] = vector
+	}
+
+	// The vectors were generated with the full match window, so the compressed data only matches it in those builds
+	// The vectors decode the same with every window size
+	fullWindow := doboz.MATCH_WINDOW_SIZE == doboz.DICTIONARY_SIZE
+
+	var d doboz.Decompressor
+
+	for _, vector := range dobozvectors.All() {
+		if fullWindow && !bytes.Equal(generated[vector.Name].Compressed, vector.Compressed) {
+			t.Errorf("%s: compressed data differs from the vector", vector.Name)
+		}
+
+		decompressed := make([]byte, len(vector.Uncompressed))
+		if result := d.Decompress(vector.Compressed, decompressed); result != doboz.RESULT_OK {
+			t.Errorf("%s: decompression failed: %v", vector.Name, result)
+		} else if !bytes.Equal(decompressed, vector.Uncompressed) {
+			t.Errorf("%s: decompressed data differs from the vector", vector.Name)
+		}
+
+		if result, s := d.DecompressToString(vector.Compressed); result != doboz.RESULT_OK || s != string(vector.Uncompressed) {
+			t.Errorf("%s: decompression to a string failed: %v", vector.Name, result)
+		}
+	}
+}
+
+func TestRegressions(t *testing.T) {
+	for _, mismatch := range dobozvectors.ReplayCorpus() {
+		t.Error(mismatch)
+	}
+}
+
+func TestImplementation(t *testing.T) {
+	want := doboz.IMPLEMENTATION_UNSAFE
+	if doboz.PureGo() {
+		want = doboz.IMPLEMENTATION_GENERIC
+	}
+
+	if got := doboz.Implementation(); got != want {
+		t.Errorf("Implementation() = %q with PureGo() = %v", got, doboz.PureGo())
+	}
+}
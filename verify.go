@@ -0,0 +1,46 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+// Checks the integrity of a compressed block without producing the uncompressed data
+// It runs the same checks as Decompress (header, match ranges and length accounting), so a block that passes
+// can be decompressed into a buffer of the uncompressed size
+// The format has no checksums, so no destination or scratch window is needed to decode match contents
+// On success, returns RESULT_OK
+func (d *Decompressor) Verify(source []byte) Result {
+	// Decode the header
+	decodeHeaderResult, header, headerSize := d.decodeBlockHeader(source)
+
+	if decodeHeaderResult != RESULT_OK {
+		return decodeHeaderResult
+	}
+
+	// Check whether the supplied buffer is large enough
+	if uint64(len(source)) < header.CompressedSize {
+		return RESULT_ERROR_BUFFER_TOO_SMALL
+	}
+
+	if d.strict && uint64(len(source)) != header.CompressedSize {
+		return RESULT_ERROR_TRAILING_DATA
+	}
+
+	// Walk all the tokens
+	tokens := d.newTokenReader(source, header, headerSize)
+
+	for {
+		result, _, ok := tokens.next()
+		if result != RESULT_OK {
+			return result
+		}
+
+		if !ok {
+			break
+		}
+	}
+
+	if d.strict && !tokens.endsExactly() {
+		return RESULT_ERROR_STREAM_SIZE_MISMATCH
+	}
+
+	return RESULT_OK
+}
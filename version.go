@@ -0,0 +1,27 @@
+//go:build !dobozencodeonly
+
+package doboz
+
+// Decodes the data of a compressed block with a given format version
+// The header has already been decoded and checked against the buffers, and stored blocks are handled by the caller
+type versionDecoder func(d *Decompressor, source []byte, destination []byte, header Header, headerSize int) Result
+
+// The decoder implementations of every known format version
+// New format revisions must be registered here, VERSION being the newest one
+var versionDecoders = map[int]versionDecoder{
+	0: (*Decompressor).decompressVersion0,
+}
+
+// Returns the decoder for a format version
+// Unknown versions newer than VERSION are decoded as VERSION if the decompressor was created with WithNewerVersions
+func (d *Decompressor) versionDecoder(version int) (versionDecoder, bool) {
+	if decoder, ok := versionDecoders[version]; ok {
+		return decoder, true
+	}
+
+	if d.newerVersions && version > VERSION {
+		return versionDecoders[VERSION], true
+	}
+
+	return nil, false
+}
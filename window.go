@@ -0,0 +1,10 @@
+//go:build !dobozdecodeonly && !tinygo && !dobozsmallwindow
+
+package doboz
+
+const (
+	// The distance the compressor looks back for matches, must be a power of 2 not larger than DICTIONARY_SIZE
+	MATCH_WINDOW_SIZE = DICTIONARY_SIZE
+
+	HASH_TABLE_SIZE = 1 << 20
+)
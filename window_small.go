@@ -0,0 +1,13 @@
+//go:build !dobozdecodeonly && (tinygo || dobozsmallwindow)
+
+package doboz
+
+// The small-window profile for TinyGo and memory constrained targets
+// The match finder needs a few hundred kilobytes instead of tens of megabytes, at the cost of compression ratio
+// The blocks it produces are ordinary blocks, which any decoder can decompress
+const (
+	// The distance the compressor looks back for matches, must be a power of 2 not larger than DICTIONARY_SIZE
+	MATCH_WINDOW_SIZE = 1 << 14
+
+	HASH_TABLE_SIZE = 1 << 12
+)